@@ -0,0 +1,224 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"aoc2022/puzzle"
+)
+
+type ElfBag struct {
+	OwnerID  int
+	Items    int
+	Calories int
+}
+
+func (bag ElfBag) String() string {
+	return fmt.Sprintf("OwnerID=%d, Items=%d, Calories=%d", bag.OwnerID, bag.Items, bag.Calories)
+}
+
+// Bigger bags go first, ties are resolved in favor of lower OwnerID
+func (bag ElfBag) Before(other ElfBag) bool {
+	if bag.Calories != other.Calories {
+		return bag.Calories > other.Calories
+	}
+	return bag.OwnerID < other.OwnerID
+}
+
+// Feed bags from input file to a callback one by one
+//
+// Bags are separated by empty lines, owners are numbered from zero in order
+// of appearance. Several empty lines in a row do not make up an empty bag.
+func ReadBags(filename string, callback func(ElfBag)) error {
+	lines, err := puzzle.ReadLines(filename)
+	if err != nil {
+		return err
+	}
+	defer lines.Close()
+	var current ElfBag
+	var lineNo int
+	for line := range lines.C {
+		lineNo++
+		if len(line) == 0 {
+			if current.Items > 0 {
+				callback(current)
+				current = ElfBag{OwnerID: current.OwnerID + 1}
+			}
+			continue
+		}
+		number, err := strconv.Atoi(line)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		current.Items += 1
+		current.Calories += number
+	}
+	err = lines.Close()
+	if err != nil {
+		return err
+	}
+	if current.Items > 0 {
+		callback(current)
+	}
+	return nil
+}
+
+// All bags from input file in order of appearance
+type Bags []ElfBag
+
+func ReadAllBags(filename string) (bags Bags, err error) {
+	err = ReadBags(filename, func(bag ElfBag) {
+		bags = append(bags, bag)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bags, nil
+}
+
+func (bags Bags) Copy() Bags {
+	return append(Bags(nil), bags...)
+}
+
+// Streaming selection of K biggest bags
+type TopBags struct {
+	limit int
+	heap  bagHeap
+}
+
+func NewTopBags(k int) *TopBags {
+	if k < 0 {
+		k = 0
+	}
+	return &TopBags{limit: k, heap: make(bagHeap, 0, k+1)}
+}
+
+func (top *TopBags) Push(bag ElfBag) {
+	if top.limit == 0 {
+		return
+	}
+	if len(top.heap) < top.limit {
+		heap.Push(&top.heap, bag)
+		return
+	}
+	if bag.Before(top.heap[0]) {
+		top.heap[0] = bag
+		heap.Fix(&top.heap, 0)
+	}
+}
+
+// Selected bags, biggest first
+func (top *TopBags) Bags() []ElfBag {
+	bags := make([]ElfBag, len(top.heap))
+	copy(bags, top.heap)
+	sort.Slice(bags, func(i, j int) bool {
+		return bags[i].Before(bags[j])
+	})
+	return bags
+}
+
+// Min-heap that keeps the smallest of selected bags on top
+type bagHeap []ElfBag
+
+func (h bagHeap) Len() int           { return len(h) }
+func (h bagHeap) Less(i, j int) bool { return h[j].Before(h[i]) }
+func (h bagHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *bagHeap) Push(x any)        { *h = append(*h, x.(ElfBag)) }
+func (h *bagHeap) Pop() any {
+	old := *h
+	last := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return last
+}
+
+// Sorted sample of per elf values
+type Distribution []int
+
+func NewDistribution(values []int) Distribution {
+	d := make(Distribution, len(values))
+	copy(d, values)
+	sort.Ints(d)
+	return d
+}
+
+func (d Distribution) Sum() (sum int) {
+	for _, value := range d {
+		sum += value
+	}
+	return sum
+}
+
+func (d Distribution) Mean() float64 {
+	if len(d) == 0 {
+		return math.NaN()
+	}
+	return float64(d.Sum()) / float64(len(d))
+}
+
+func (d Distribution) Median() float64 {
+	return d.Percentile(50)
+}
+
+// Linear interpolation between closest ranks, NaN for p outside of [0, 100]
+func (d Distribution) Percentile(p float64) float64 {
+	if len(d) == 0 || math.IsNaN(p) || p < 0 || p > 100 {
+		return math.NaN()
+	}
+	rank := p / 100 * float64(len(d)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	fraction := rank - float64(lower)
+	return float64(d[lower]) + fraction*float64(d[upper]-d[lower])
+}
+
+func (d Distribution) String() string {
+	return fmt.Sprintf(
+		"count=%d, mean=%.1f, median=%.1f, p90=%.1f, max=%.1f",
+		len(d),
+		d.Mean(),
+		d.Median(),
+		d.Percentile(90),
+		d.Percentile(100),
+	)
+}
+
+// Top K bags along with statistics for all elves
+type Ledger struct {
+	top      *TopBags
+	calories []int
+	items    []int
+}
+
+func NewLedger(k int) *Ledger {
+	return &Ledger{top: NewTopBags(k)}
+}
+
+// Collect statistics for the given bags
+func (bags Bags) Ledger(k int) *Ledger {
+	ledger := NewLedger(k)
+	for _, bag := range bags {
+		ledger.Add(bag)
+	}
+	return ledger
+}
+
+func (l *Ledger) Add(bag ElfBag) {
+	l.top.Push(bag)
+	l.calories = append(l.calories, bag.Calories)
+	l.items = append(l.items, bag.Items)
+}
+
+func (l *Ledger) Top() []ElfBag {
+	return l.top.Bags()
+}
+
+func (l *Ledger) Calories() Distribution {
+	return NewDistribution(l.calories)
+}
+
+func (l *Ledger) Items() Distribution {
+	return NewDistribution(l.items)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"aoc2022/puzzle/puzzletest"
+)
+
+const sample = "sample.txt"
+
+func TestSample(t *testing.T) {
+	tests := []struct {
+		worker func(Bags) string
+		input  string
+		result string
+	}{
+		{worker: part1, input: sample, result: "24000"},
+		{worker: part2, input: sample, result: "45000"},
+	}
+	parsed := make(map[string]Bags)
+	for i, test := range tests {
+		input, ok := parsed[test.input]
+		if !ok {
+			var err error
+			input, err = ReadAllBags(test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			parsed[test.input] = input
+		}
+		got := test.worker(input.Copy())
+		if got != test.result {
+			t.Errorf("sample: part %d expected %q, got %q", i%2+1, test.result, got)
+		}
+	}
+}
+
+func TestReadBags(t *testing.T) {
+	input := puzzletest.WriteInput(t, "\n\n1000\n2000\n\n\n\n3000\n\n\n")
+	var bags []ElfBag
+	err := ReadBags(input, func(bag ElfBag) {
+		bags = append(bags, bag)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ElfBag{
+		{OwnerID: 0, Items: 2, Calories: 3000},
+		{OwnerID: 1, Items: 1, Calories: 3000},
+	}
+	if len(bags) != len(want) {
+		t.Fatalf("want %v, got %v", want, bags)
+	}
+	for i := range want {
+		if bags[i] != want[i] {
+			t.Errorf("bag #%d: want %v, got %v", i, want[i], bags[i])
+		}
+	}
+
+	input = puzzletest.WriteInput(t, "100\nabc\n"+strings.Repeat("100\n", 1000))
+	err = ReadBags(input, func(ElfBag) {})
+	if err == nil {
+		t.Errorf("invalid line accepted")
+	}
+}
+
+func TestTopBags(t *testing.T) {
+	bags := []ElfBag{
+		{OwnerID: 0, Calories: 100},
+		{OwnerID: 1, Calories: 300},
+		{OwnerID: 2, Calories: 200},
+		{OwnerID: 3, Calories: 300},
+		{OwnerID: 4, Calories: 200},
+		{OwnerID: 5, Calories: 50},
+	}
+	tests := []struct {
+		k      int
+		owners []int
+	}{
+		{0, []int{}},
+		{1, []int{1}},
+		{2, []int{1, 3}},
+		{3, []int{1, 3, 2}},
+		{4, []int{1, 3, 2, 4}},
+		{10, []int{1, 3, 2, 4, 0, 5}},
+	}
+	for _, test := range tests {
+		top := NewTopBags(test.k)
+		for _, bag := range bags {
+			top.Push(bag)
+		}
+		got := top.Bags()
+		if len(got) != len(test.owners) {
+			t.Errorf("k=%d: want %v, got %v", test.k, test.owners, got)
+			continue
+		}
+		for i, bag := range got {
+			if bag.OwnerID != test.owners[i] {
+				t.Errorf("k=%d: want owners %v, got %v", test.k, test.owners, got)
+				break
+			}
+		}
+	}
+}
+
+func TestDistribution(t *testing.T) {
+	d := NewDistribution([]int{4000, 24000, 6000, 11000, 10000})
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"mean", d.Mean(), 11000},
+		{"median", d.Median(), 10000},
+		{"p0", d.Percentile(0), 4000},
+		{"p25", d.Percentile(25), 6000},
+		{"p90", d.Percentile(90), 18800},
+		{"p100", d.Percentile(100), 24000},
+	}
+	for _, test := range tests {
+		if math.Abs(test.got-test.want) > 1e-9 {
+			t.Errorf("%s: want %v, got %v", test.name, test.want, test.got)
+		}
+	}
+	if !math.IsNaN(Distribution{}.Median()) {
+		t.Errorf("median of empty distribution must be NaN")
+	}
+	for _, p := range []float64{-1, 100.5, math.NaN(), math.Inf(1)} {
+		if got := d.Percentile(p); !math.IsNaN(got) {
+			t.Errorf("percentile %v: want NaN, got %v", p, got)
+		}
+	}
+}
+
+func BenchmarkPart2(b *testing.B) {
+	input, err := ReadAllBags(sample)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		part2(input.Copy())
+	}
+}
@@ -5,34 +5,11 @@ import (
 	"strconv"
 )
 
-type ElfBag struct {
-	OwnerID  int
-	Items    int
-	Calories int
-}
-
-func part1(input string) {
-	log.Println("Day 1 Part 1")
-	var current, biggest ElfBag
-	for line := range ReadLines(input) {
-		if len(line) == 0 {
-			if current.Calories > biggest.Calories {
-				biggest = current
-			}
-			current = ElfBag{OwnerID: current.OwnerID + 1}
-			continue
-		}
-		number, err := strconv.Atoi(line)
-		if err != nil {
-			log.Fatal(err)
-		}
-		current.Items += 1
-		current.Calories += number
+func part1(bags Bags) string {
+	top := bags.Ledger(1).Top()
+	if len(top) == 0 {
+		log.Fatal("no bags found in input")
 	}
-	log.Printf(
-		"Biggest bag: OwnerID=%d, Items=%d, Calories=%d",
-		biggest.OwnerID,
-		biggest.Items,
-		biggest.Calories,
-	)
+	log.Printf("Biggest bag: %v", top[0])
+	return strconv.Itoa(top[0].Calories)
 }
@@ -0,0 +1,376 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	limitSpec = flag.String("limit", "", "-query analyze: max number of times each move may be used, e.g. `Rock=10,Paper=5`")
+	noRepeat  = flag.Bool("norepeat", false, "-query analyze: never play the same move in two consecutive rounds")
+	mixedSpec = flag.String("mixed", "", "-query analyze: opponent's mixed strategy, e.g. `Rock=0.5,Paper=0.5` (default: move frequencies from guide)")
+)
+
+// Restrictions on our choice of moves
+type Constraints struct {
+	Limit    map[GameMove]int // moves not listed here are unlimited
+	NoRepeat bool
+}
+
+func (game *Game) FormatConstraints(c Constraints) string {
+	var rules []string
+	for move, limit := range c.Limit {
+		rules = append(rules, fmt.Sprintf("%s at most %d times", game.Moves[move].Name, limit))
+	}
+	sort.Strings(rules)
+	if c.NoRepeat {
+		rules = append(rules, "no repeats")
+	}
+	if len(rules) == 0 {
+		return "none"
+	}
+	return strings.Join(rules, ", ")
+}
+
+// Parse comma separated list of Name=Value pairs
+func (game *Game) parseMoveValues(spec string, parse func(string) (float64, error)) (map[GameMove]float64, error) {
+	values := make(map[GameMove]float64)
+	if spec == "" {
+		return values, nil
+	}
+	index := make(map[string]GameMove, game.Size())
+	for i, move := range game.Moves {
+		index[move.Name] = GameMove(i)
+	}
+	for _, chunk := range strings.Split(spec, ",") {
+		name, raw, ok := strings.Cut(strings.TrimSpace(chunk), "=")
+		if !ok {
+			return nil, fmt.Errorf("expected Name=Value, got %q", chunk)
+		}
+		move, ok := index[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown move: %s", name)
+		}
+		value, err := parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		values[move] = value
+	}
+	return values, nil
+}
+
+func (game *Game) ParseConstraints(limits string, noRepeat bool) (Constraints, error) {
+	values, err := game.parseMoveValues(limits, func(raw string) (float64, error) {
+		limit, err := strconv.Atoi(raw)
+		if err == nil && limit < 0 {
+			err = fmt.Errorf("negative limit: %d", limit)
+		}
+		return float64(limit), err
+	})
+	if err != nil {
+		return Constraints{}, err
+	}
+	rules := Constraints{NoRepeat: noRepeat}
+	if len(values) > 0 {
+		rules.Limit = make(map[GameMove]int, len(values))
+		for move, limit := range values {
+			rules.Limit[move] = int(limit)
+		}
+	}
+	return rules, nil
+}
+
+// Best total score we can get against known opponent moves
+func (game *Game) BestScore(opponent []GameMove, rules Constraints) (int, error) {
+	if len(rules.Limit) > 0 && !rules.NoRepeat {
+		return game.bestAssignment(opponent, rules.Limit)
+	}
+	return game.bestSequence(opponent, rules)
+}
+
+const maxSearchStates = 1 << 16
+
+// Dynamic programming over rounds
+//
+// Search state is the last move played and the number of times each limited
+// move was used so far. Usage counters are packed into a single mixed radix
+// number.
+func (game *Game) bestSequence(opponent []GameMove, rules Constraints) (int, error) {
+	type state struct {
+		last GameMove
+		used int64
+	}
+	radix := make([]int64, game.Size()) // place value of each move's counter, zero for unlimited moves
+	var places int64 = 1
+	for move := 0; move < game.Size(); move++ {
+		limit, limited := rules.Limit[GameMove(move)]
+		if !limited {
+			continue
+		}
+		radix[move] = places
+		if places > math.MaxInt64/int64(limit+1) {
+			return 0, fmt.Errorf("too many combinations of move limits: %s", game.FormatConstraints(rules))
+		}
+		places *= int64(limit + 1)
+	}
+	count := func(s state, move GameMove) int {
+		if radix[move] == 0 {
+			return 0
+		}
+		return int(s.used / radix[move] % int64(rules.Limit[move]+1))
+	}
+
+	layer := map[state]int{{last: -1}: 0}
+	for round, them := range opponent {
+		next := make(map[state]int)
+		for s, score := range layer {
+			for move := GameMove(0); int(move) < game.Size(); move++ {
+				if rules.NoRepeat && move == s.last {
+					continue
+				}
+				if radix[move] != 0 && count(s, move) >= rules.Limit[move] {
+					continue
+				}
+				after := state{last: move, used: s.used + radix[move]}
+				if !rules.NoRepeat {
+					after.last = -1 // last move does not matter, merge states
+				}
+				total := score + (&GameRound{Game: game, Them: them, Us: move}).Score()
+				if best, seen := next[after]; !seen || total > best {
+					next[after] = total
+				}
+			}
+		}
+		if len(next) == 0 {
+			return 0, fmt.Errorf("constraints can not be satisfied beyond round %d: %s", round, game.FormatConstraints(rules))
+		}
+		if len(next) > maxSearchStates {
+			return 0, fmt.Errorf("search space too large at round %d: %d states", round+1, len(next))
+		}
+		layer = next
+	}
+	best := math.MinInt
+	for _, score := range layer {
+		if score > best {
+			best = score
+		}
+	}
+	if best == math.MinInt {
+		best = 0 // no rounds played
+	}
+	return best, nil
+}
+
+// Without ordering constraints only the number of rounds against each
+// opponent move matters, which turns the problem into a tiny transportation
+// problem: opponent moves supply rounds, our moves consume them within their
+// limits. It is solved as min cost flow with successive shortest paths.
+func (game *Game) bestAssignment(opponent []GameMove, limits map[GameMove]int) (int, error) {
+	n := game.Size()
+	source, sink := 0, 2*n+1
+	theirs := func(move int) int { return 1 + move }
+	ours := func(move int) int { return 1 + n + move }
+
+	var net flowNetwork
+	net.init(2*n + 2)
+	supply := make([]int, n)
+	for _, them := range opponent {
+		supply[them]++
+	}
+	for them := 0; them < n; them++ {
+		net.add(source, theirs(them), supply[them], 0)
+		for us := 0; us < n; us++ {
+			score := (&GameRound{Game: game, Them: GameMove(them), Us: GameMove(us)}).Score()
+			net.add(theirs(them), ours(us), len(opponent), -score)
+		}
+	}
+	for us := 0; us < n; us++ {
+		capacity := len(opponent)
+		if limit, limited := limits[GameMove(us)]; limited && limit < capacity {
+			capacity = limit
+		}
+		net.add(ours(us), sink, capacity, 0)
+	}
+	flow, cost := net.minCostFlow(source, sink)
+	if flow < len(opponent) {
+		return 0, fmt.Errorf("move limits allow only %d rounds out of %d", flow, len(opponent))
+	}
+	return -cost, nil
+}
+
+type flowEdge struct {
+	to, capacity, cost int
+}
+
+type flowNetwork struct {
+	edges []flowEdge
+	graph [][]int // node -> indexes of outgoing edges, reverse edge is always at index^1
+}
+
+func (net *flowNetwork) init(nodes int) {
+	net.graph = make([][]int, nodes)
+}
+
+func (net *flowNetwork) add(from, to, capacity, cost int) {
+	net.graph[from] = append(net.graph[from], len(net.edges))
+	net.edges = append(net.edges, flowEdge{to, capacity, cost})
+	net.graph[to] = append(net.graph[to], len(net.edges))
+	net.edges = append(net.edges, flowEdge{from, 0, -cost})
+}
+
+// Bellman-Ford is fine here: networks are tiny and some costs are negative
+func (net *flowNetwork) minCostFlow(source, sink int) (flow, cost int) {
+	nodes := len(net.graph)
+	for {
+		distance := make([]int, nodes)
+		via := make([]int, nodes)
+		for i := range distance {
+			distance[i] = math.MaxInt
+			via[i] = -1
+		}
+		distance[source] = 0
+		for changed := true; changed; {
+			changed = false
+			for node := 0; node < nodes; node++ {
+				if distance[node] == math.MaxInt {
+					continue
+				}
+				for _, e := range net.graph[node] {
+					edge := net.edges[e]
+					if edge.capacity > 0 && distance[node]+edge.cost < distance[edge.to] {
+						distance[edge.to] = distance[node] + edge.cost
+						via[edge.to] = e
+						changed = true
+					}
+				}
+			}
+		}
+		if distance[sink] == math.MaxInt {
+			return flow, cost
+		}
+		push := math.MaxInt
+		for node := sink; node != source; node = net.edges[via[node]^1].to {
+			if c := net.edges[via[node]].capacity; c < push {
+				push = c
+			}
+		}
+		for node := sink; node != source; node = net.edges[via[node]^1].to {
+			net.edges[via[node]].capacity -= push
+			net.edges[via[node]^1].capacity += push
+		}
+		flow += push
+		cost += push * distance[sink]
+	}
+}
+
+// Probability of choosing each move
+type Mixed []float64
+
+func (game *Game) ParseMixed(spec string) (Mixed, error) {
+	values, err := game.parseMoveValues(spec, func(raw string) (float64, error) {
+		p, err := strconv.ParseFloat(raw, 64)
+		if err == nil && (p < 0 || p > 1) {
+			err = fmt.Errorf("probability out of range: %v", p)
+		}
+		return p, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	mix := make(Mixed, game.Size())
+	var total float64
+	for move, p := range values {
+		mix[move] = p
+		total += p
+	}
+	if math.Abs(total-1) > 1e-9 {
+		return nil, fmt.Errorf("probabilities must add up to 1, got %v", total)
+	}
+	return mix, nil
+}
+
+// Empirical strategy: how often each move was played
+func (game *Game) Frequencies(moves []GameMove) Mixed {
+	mix := make(Mixed, game.Size())
+	for _, move := range moves {
+		mix[move] += 1 / float64(len(moves))
+	}
+	return mix
+}
+
+// Expected score of a player picking moves at random against a known move
+func (game *Game) Expected(mix Mixed, against GameMove) (score float64) {
+	for move, p := range mix {
+		score += p * float64((&GameRound{Game: game, Them: against, Us: GameMove(move)}).Score())
+	}
+	return score
+}
+
+func (game *Game) FormatMixed(mix Mixed) string {
+	chunks := make([]string, len(mix))
+	for move, p := range mix {
+		chunks[move] = fmt.Sprintf("%s=%.3f", game.Moves[move].Name, p)
+	}
+	return strings.Join(chunks, ",")
+}
+
+// Game theory report for the strategy guide
+func (s *Strategy) Analyze(rules Constraints, mix Mixed) (string, error) {
+	byMove, err := s.ByMove()
+	if err != nil {
+		return "", err
+	}
+	byOffset, err := s.ByOffset()
+	if err != nil {
+		return "", err
+	}
+	opponent := make([]GameMove, len(byOffset))
+	for i, round := range byOffset {
+		opponent[i] = round.Them
+	}
+	best, err := s.Game.BestScore(opponent, rules)
+	if err != nil {
+		return "", err
+	}
+	if mix == nil {
+		mix = s.Game.Frequencies(opponent)
+	}
+	var expected float64
+	for _, round := range byOffset {
+		expected += s.Game.Expected(mix, round.Us)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Game: %v\n", s.Game)
+	fmt.Fprintf(&b, "Guide score, second column is our move: %d\n", TotalScore(byMove))
+	fmt.Fprintf(&b, "Guide score, second column is the outcome: %d\n", TotalScore(byOffset))
+	fmt.Fprintf(&b, "Best score (constraints: %s): %d\n", s.Game.FormatConstraints(rules), best)
+	fmt.Fprintf(&b, "Opponent's expected score with %s: %.1f", s.Game.FormatMixed(mix), expected)
+	return b.String(), nil
+}
+
+// Answer a question about the strategy guide:
+//
+//	analyze    game theory report, see -limit, -norepeat and -mixed
+func query(strategy *Strategy, question string) (string, error) {
+	if strings.TrimSpace(question) != "analyze" {
+		return "", fmt.Errorf("unsupported query: %q (expected: analyze)", question)
+	}
+	rules, err := strategy.Game.ParseConstraints(*limitSpec, *noRepeat)
+	if err != nil {
+		return "", fmt.Errorf("invalid -limit: %w", err)
+	}
+	var mix Mixed
+	if *mixedSpec != "" {
+		mix, err = strategy.Game.ParseMixed(*mixedSpec)
+		if err != nil {
+			return "", fmt.Errorf("invalid -mixed: %w", err)
+		}
+	}
+	return strategy.Analyze(rules, mix)
+}
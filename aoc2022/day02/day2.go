@@ -1,8 +1,13 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"log"
+	"strconv"
 	"strings"
+
+	"aoc2022/puzzle"
 )
 
 type GameMove int
@@ -14,95 +19,146 @@ const (
 )
 
 type GameRound struct {
+	Game *Game
 	Them GameMove
 	Us   GameMove
 }
 
+// Round result from our point of view: 1 if we won, 0 for draw, -1 if we lost
+func (g *GameRound) Result() int {
+	n := g.Game.Size()
+	diff := ((int(g.Us-g.Them))%n + n) % n // golang modulo operator is special
+	switch {
+	case diff == 0:
+		return 0
+	case diff <= n/2:
+		return 1
+	default:
+		return -1
+	}
+}
+
 func (g *GameRound) Outcome() int {
-	result := (g.Us - g.Them + 3) % 3 // golang modulo operator is special
-	switch result {
-	case 0:
-		return 3 // draw
+	switch g.Result() {
 	case 1:
-		return 6 // we won
-	case 2:
-		return 0 // we lost
+		return g.Game.Win
+	case -1:
+		return g.Game.Loss
 	default:
-		log.Fatalf("unexpected result: %d (round %v)", result, g)
-		return 0
+		return g.Game.Draw
 	}
 }
 
 func (g *GameRound) Score() int {
-	return 1 + int(g.Us) + g.Outcome()
+	return g.Game.Moves[g.Us].Score + g.Outcome()
 }
 
 func (g *GameRound) Valid() bool {
-	return g.Us >= 0 && g.Us < 3 && g.Them >= 0 && g.Them < 3
+	return g.Game.Valid(g.Us) && g.Game.Valid(g.Them)
+}
+
+// Single line of encrypted strategy guide
+type Hint struct {
+	Them string
+	Us   string
 }
 
-func part1(filename string) {
-	opponentMoves := map[string]GameMove{
-		"A": Rock,
-		"B": Paper,
-		"C": Scissors,
+type Strategy struct {
+	Game  *Game
+	Guide []Hint
+}
+
+var gameConfig = flag.String("game", "", "load cyclic game definition from JSON `file` (default: rock paper scissors)")
+
+func ReadStrategy(filename string) (*Strategy, error) {
+	strategy := &Strategy{Game: DefaultGame()}
+	if *gameConfig != "" {
+		game, err := LoadGame(*gameConfig)
+		if err != nil {
+			return nil, err
+		}
+		strategy.Game = game
 	}
-	ourMoves := map[string]GameMove{
-		"X": Rock,
-		"Y": Paper,
-		"Z": Scissors,
+	lines, err := puzzle.ReadLines(filename)
+	if err != nil {
+		return nil, err
 	}
-	var moves []string
-	var score int
-	for line := range ReadLines(filename) {
-		moves = strings.Split(line, " ")
-		if len(moves) != 2 {
-			log.Fatalf("unexpected input line: %s", line)
-		}
-		round := GameRound{
-			Us:   ourMoves[moves[1]],
-			Them: opponentMoves[moves[0]],
+	defer lines.Close()
+	for line := range lines.C {
+		letters := strings.Fields(line)
+		if len(letters) != 2 {
+			return nil, fmt.Errorf("unexpected input line: %s", line)
 		}
-		if !round.Valid() {
-			log.Fatalf("invalid moves parsed: %v", round)
-		}
-		score += round.Score()
-		//log.Printf("Round %v, score %d", round, round.Score())
+		strategy.Guide = append(strategy.Guide, Hint{Them: letters[0], Us: letters[1]})
 	}
-	log.Printf("Part 1 score: %d", score)
+	err = lines.Close()
+	if err != nil {
+		return nil, err
+	}
+	return strategy, nil
 }
 
-func part2(filename string) {
-	opponentMoves := map[string]GameMove{
-		"A": Rock,
-		"B": Paper,
-		"C": Scissors,
-	}
-	outcomes := map[string]int{
-		"X": -1, // lose
-		"Y": 0,  // draw
-		"Z": 1,  // win
+func (s *Strategy) Copy() *Strategy {
+	return &Strategy{
+		Game:  s.Game, // never modified after loading
+		Guide: append([]Hint(nil), s.Guide...),
 	}
-	var moves []string
-	var score int
-	for line := range ReadLines(filename) {
-		moves = strings.Split(line, " ")
-		if len(moves) != 2 {
-			log.Fatalf("unexpected input line: %s", line)
-		}
-		round := GameRound{
-			Them: opponentMoves[moves[0]],
+}
+
+// Rounds played if the second column is our move
+func (s *Strategy) ByMove() ([]GameRound, error) {
+	return s.rounds(func(them GameMove, letter string) (GameMove, bool) {
+		move, ok := s.Game.Player[letter]
+		return move, ok
+	})
+}
+
+// Rounds played if the second column is our move relative to opponent's
+func (s *Strategy) ByOffset() ([]GameRound, error) {
+	return s.rounds(func(them GameMove, letter string) (GameMove, bool) {
+		offset, ok := s.Game.Offset[letter]
+		return s.Game.Shift(them, offset), ok
+	})
+}
+
+func (s *Strategy) rounds(decode func(GameMove, string) (GameMove, bool)) ([]GameRound, error) {
+	rounds := make([]GameRound, len(s.Guide))
+	for i, hint := range s.Guide {
+		them, ok := s.Game.Opponent[hint.Them]
+		if !ok {
+			return nil, fmt.Errorf("line %d: unknown opponent move: %s", i+1, hint.Them)
 		}
-		delta, ok := outcomes[moves[1]]
+		us, ok := decode(them, hint.Us)
 		if !ok {
-			log.Fatalf("invalid input: %s", line)
+			return nil, fmt.Errorf("line %d: unknown hint: %s", i+1, hint.Us)
 		}
-		round.Us = GameMove((int(round.Them) + delta + 3) % 3)
-		if !round.Valid() {
-			log.Fatalf("invalid round: %v (from line %q)", round, line)
+		rounds[i] = GameRound{Game: s.Game, Them: them, Us: us}
+		if !rounds[i].Valid() {
+			return nil, fmt.Errorf("line %d: invalid round: %v", i+1, rounds[i])
 		}
+	}
+	return rounds, nil
+}
+
+func TotalScore(rounds []GameRound) (score int) {
+	for _, round := range rounds {
 		score += round.Score()
-		//log.Printf("Round %v, score %d", round, round.Score())
 	}
-	log.Printf("Part 2 score: %d", score)
+	return score
+}
+
+func part1(strategy *Strategy) string {
+	rounds, err := strategy.ByMove()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return strconv.Itoa(TotalScore(rounds))
+}
+
+func part2(strategy *Strategy) string {
+	rounds, err := strategy.ByOffset()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return strconv.Itoa(TotalScore(rounds))
 }
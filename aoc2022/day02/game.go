@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Cyclic game with odd number of moves
+//
+// Moves are listed in cyclic order: each move beats (N-1)/2 moves preceding
+// it and loses to (N-1)/2 moves following it. Rock-Paper-Scissors is the
+// smallest such game, Rock-Spock-Paper-Lizard-Scissors is the next one.
+type Game struct {
+	Name  string
+	Moves []MoveInfo
+
+	// Points awarded for each outcome
+	Loss, Draw, Win int
+
+	// Letters used in strategy guide
+	Opponent map[string]GameMove // opponent's move
+	Player   map[string]GameMove // our move, first interpretation
+	Offset   map[string]int      // our move relative to opponent's, second interpretation
+}
+
+type MoveInfo struct {
+	Name  string `json:"name"`
+	Score int    `json:"score"` // points for choosing this move
+}
+
+// Game definition as stored in config file, moves are referenced by name
+type GameConfig struct {
+	Name    string            `json:"name"`
+	Moves   []MoveInfo        `json:"moves"`
+	Points  map[string]int    `json:"points"`
+	Them    map[string]string `json:"opponent"`
+	Us      map[string]string `json:"player"`
+	Offsets map[string]int    `json:"offset"`
+}
+
+func LoadGame(filename string) (*Game, error) {
+	raw, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var config GameConfig
+	err = json.Unmarshal(raw, &config)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	game, err := config.Game()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return game, nil
+}
+
+func (config *GameConfig) Game() (*Game, error) {
+	n := len(config.Moves)
+	if n < 3 || n%2 == 0 {
+		return nil, fmt.Errorf("cyclic game requires odd number of moves (3 or more), got %d", n)
+	}
+	index := make(map[string]GameMove, n)
+	for i, move := range config.Moves {
+		if _, duplicate := index[move.Name]; duplicate {
+			return nil, fmt.Errorf("duplicate move: %s", move.Name)
+		}
+		index[move.Name] = GameMove(i)
+	}
+	game := &Game{
+		Name:     config.Name,
+		Moves:    config.Moves,
+		Loss:     config.Points["loss"],
+		Draw:     config.Points["draw"],
+		Win:      config.Points["win"],
+		Opponent: make(map[string]GameMove, len(config.Them)),
+		Player:   make(map[string]GameMove, len(config.Us)),
+		Offset:   make(map[string]int, len(config.Offsets)),
+	}
+	for _, mapping := range []struct {
+		letters map[string]string
+		moves   map[string]GameMove
+	}{
+		{config.Them, game.Opponent},
+		{config.Us, game.Player},
+	} {
+		for letter, name := range mapping.letters {
+			move, ok := index[name]
+			if !ok {
+				return nil, fmt.Errorf("letter %s refers to unknown move: %s", letter, name)
+			}
+			mapping.moves[letter] = move
+		}
+	}
+	for letter, offset := range config.Offsets {
+		if offset < -n/2 || offset > n/2 {
+			return nil, fmt.Errorf("letter %s: offset %d out of range [%d, %d]", letter, offset, -n/2, n/2)
+		}
+		game.Offset[letter] = offset
+	}
+	return game, nil
+}
+
+// Day 2 rules
+func DefaultGame() *Game {
+	config := &GameConfig{
+		Name: "Rock Paper Scissors",
+		Moves: []MoveInfo{
+			{"Rock", 1},
+			{"Paper", 2},
+			{"Scissors", 3},
+		},
+		Points:  map[string]int{"loss": 0, "draw": 3, "win": 6},
+		Them:    map[string]string{"A": "Rock", "B": "Paper", "C": "Scissors"},
+		Us:      map[string]string{"X": "Rock", "Y": "Paper", "Z": "Scissors"},
+		Offsets: map[string]int{"X": -1, "Y": 0, "Z": 1},
+	}
+	game, err := config.Game()
+	if err != nil {
+		panic(err)
+	}
+	return game
+}
+
+func (game *Game) Size() int {
+	return len(game.Moves)
+}
+
+func (game *Game) Valid(move GameMove) bool {
+	return move >= 0 && int(move) < game.Size()
+}
+
+// Move at the given cyclic offset: positive offsets win, negative lose
+func (game *Game) Shift(move GameMove, offset int) GameMove {
+	n := game.Size()
+	return GameMove(((int(move)+offset)%n + n) % n)
+}
+
+func (game *Game) String() string {
+	return fmt.Sprintf("%s (%d moves)", game.Name, game.Size())
+}
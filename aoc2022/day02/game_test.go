@@ -0,0 +1,201 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestSample(t *testing.T) {
+	tests := []struct {
+		worker func(*Strategy) string
+		game   string
+		input  string
+		result string
+	}{
+		{worker: part1, input: "sample.txt", result: "15"},
+		{worker: part2, input: "sample.txt", result: "12"},
+		{worker: part1, game: "rpsls.json", input: "sample-rpsls.txt", result: "21"},
+		{worker: part2, game: "rpsls.json", input: "sample-rpsls.txt", result: "30"},
+	}
+	defer func(saved string) { *gameConfig = saved }(*gameConfig)
+	for i, test := range tests {
+		*gameConfig = test.game
+		strategy, err := ReadStrategy(test.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := test.worker(strategy.Copy())
+		if got != test.result {
+			t.Errorf("%s: part %d expected %q, got %q", test.input, i%2+1, test.result, got)
+		}
+	}
+}
+
+func TestRules(t *testing.T) {
+	game, err := LoadGame("rpsls.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	beats := map[string][]string{
+		"Rock":     {"Scissors", "Lizard"},
+		"Paper":    {"Rock", "Spock"},
+		"Scissors": {"Paper", "Lizard"},
+		"Lizard":   {"Paper", "Spock"},
+		"Spock":    {"Rock", "Scissors"},
+	}
+	index := make(map[string]GameMove)
+	for i, move := range game.Moves {
+		index[move.Name] = GameMove(i)
+	}
+	for winner, losers := range beats {
+		for _, loser := range losers {
+			round := GameRound{Game: game, Us: index[winner], Them: index[loser]}
+			if round.Result() != 1 {
+				t.Errorf("%s must beat %s", winner, loser)
+			}
+			round.Us, round.Them = round.Them, round.Us
+			if round.Result() != -1 {
+				t.Errorf("%s must lose to %s", loser, winner)
+			}
+		}
+	}
+	for offset := -2; offset <= 2; offset++ {
+		for move := range game.Moves {
+			round := GameRound{Game: game, Them: GameMove(move)}
+			round.Us = game.Shift(round.Them, offset)
+			want := 0
+			if offset > 0 {
+				want = 1
+			} else if offset < 0 {
+				want = -1
+			}
+			if round.Result() != want {
+				t.Errorf("offset %d from %s: want result %d, got %d", offset, game.Moves[move].Name, want, round.Result())
+			}
+		}
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+	tests := []GameConfig{
+		{Moves: []MoveInfo{{"A", 1}, {"B", 2}}},
+		{Moves: []MoveInfo{{"A", 1}, {"B", 2}, {"C", 3}, {"D", 4}}},
+		{Moves: []MoveInfo{{"A", 1}, {"A", 2}, {"C", 3}}},
+		{Moves: []MoveInfo{{"A", 1}, {"B", 2}, {"C", 3}}, Them: map[string]string{"X": "D"}},
+		{Moves: []MoveInfo{{"A", 1}, {"B", 2}, {"C", 3}}, Offsets: map[string]int{"X": 2}},
+	}
+	for i, config := range tests {
+		if _, err := config.Game(); err == nil {
+			t.Errorf("config #%d: want error, got nil", i)
+		}
+	}
+}
+
+// Try every possible sequence of our moves
+func bruteForce(game *Game, opponent []GameMove, rules Constraints) (best int, ok bool) {
+	used := make(map[GameMove]int)
+	var search func(round int, last GameMove, score int)
+	search = func(round int, last GameMove, score int) {
+		if round == len(opponent) {
+			if !ok || score > best {
+				best, ok = score, true
+			}
+			return
+		}
+		for move := GameMove(0); int(move) < game.Size(); move++ {
+			if rules.NoRepeat && move == last {
+				continue
+			}
+			if limit, limited := rules.Limit[move]; limited && used[move] >= limit {
+				continue
+			}
+			used[move]++
+			search(round+1, move, score+(&GameRound{Game: game, Them: opponent[round], Us: move}).Score())
+			used[move]--
+		}
+	}
+	search(0, -1, 0)
+	return best, ok
+}
+
+func TestBestScore(t *testing.T) {
+	rpsls, err := LoadGame("rpsls.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	random := rand.New(rand.NewSource(2022))
+	for _, game := range []*Game{DefaultGame(), rpsls} {
+		for i := 0; i < 50; i++ {
+			opponent := make([]GameMove, 1+random.Intn(7))
+			for j := range opponent {
+				opponent[j] = GameMove(random.Intn(game.Size()))
+			}
+			rules := Constraints{NoRepeat: random.Intn(2) == 0}
+			if random.Intn(3) > 0 {
+				rules.Limit = map[GameMove]int{
+					GameMove(random.Intn(game.Size())): random.Intn(4),
+					GameMove(random.Intn(game.Size())): random.Intn(4),
+				}
+			}
+			want, ok := bruteForce(game, opponent, rules)
+			got, err := game.BestScore(opponent, rules)
+			if !ok {
+				if err == nil {
+					t.Errorf("%v vs %v (%s): want error, got %d", game, opponent, game.FormatConstraints(rules), got)
+				}
+				continue
+			}
+			if err != nil || got != want {
+				t.Errorf("%v vs %v (%s): want %d, got %d (%v)", game, opponent, game.FormatConstraints(rules), want, got, err)
+			}
+		}
+	}
+}
+
+func TestMixed(t *testing.T) {
+	game := DefaultGame()
+	mix, err := game.ParseMixed("Rock=0.5,Scissors=0.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Against Paper: Rock loses (1+0), Scissors wins (3+6)
+	if got := game.Expected(mix, Paper); math.Abs(got-5) > 1e-9 {
+		t.Errorf("expected score against Paper: want 5, got %v", got)
+	}
+	freq := game.Frequencies([]GameMove{Rock, Rock, Paper, Scissors})
+	for move, want := range []float64{0.5, 0.25, 0.25} {
+		if math.Abs(freq[move]-want) > 1e-9 {
+			t.Errorf("frequency of %s: want %v, got %v", game.Moves[move].Name, want, freq[move])
+		}
+	}
+	for _, spec := range []string{"Rock=0.5", "Rock=1.5,Paper=-0.5", "Rock", "Lizard=1"} {
+		if _, err := game.ParseMixed(spec); err == nil {
+			t.Errorf("ParseMixed(%q): want error, got nil", spec)
+		}
+	}
+}
+
+func TestQuery(t *testing.T) {
+	strategy, err := ReadStrategy("sample.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := query(strategy.Copy(), "analyze")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Guide score, second column is our move: 15",
+		"Guide score, second column is the outcome: 12",
+		"Best score (constraints: none): 24",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("want %q in report:\n%s", want, report)
+		}
+	}
+	if _, err := query(strategy.Copy(), "solve"); err == nil {
+		t.Errorf("unsupported query accepted")
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"math/bits"
+)
+
+// Set of item types, bit N-1 stands for the item with priority N
+type ItemSet uint64
+
+const allItems ItemSet = 1<<52 - 1
+
+func NewItemSet(items string) (ItemSet, error) {
+	var set ItemSet
+	for _, r := range items {
+		score, err := itemScore(r)
+		if err != nil {
+			return 0, err
+		}
+		set |= 1 << (score - 1)
+	}
+	return set, nil
+}
+
+// Items present in all sets
+func Intersect(sets ...ItemSet) ItemSet {
+	if len(sets) == 0 {
+		return 0
+	}
+	shared := allItems
+	for _, set := range sets {
+		shared &= set
+	}
+	return shared
+}
+
+func (set ItemSet) Contains(r rune) bool {
+	score, err := itemScore(r)
+	if err != nil {
+		return false
+	}
+	return set&(1<<(score-1)) != 0
+}
+
+func (set ItemSet) Len() int {
+	return bits.OnesCount64(uint64(set))
+}
+
+// Item types in order of priority
+func (set ItemSet) Items() []rune {
+	items := make([]rune, 0, set.Len())
+	for rest := uint64(set); rest != 0; rest &= rest - 1 {
+		score := bits.TrailingZeros64(rest) + 1
+		if score <= 26 {
+			items = append(items, rune('a'+score-1))
+		} else {
+			items = append(items, rune('A'+score-27))
+		}
+	}
+	return items
+}
+
+// Sum of priorities of all item types in set
+func (set ItemSet) Score() (total int) {
+	for rest := uint64(set); rest != 0; rest &= rest - 1 {
+		total += bits.TrailingZeros64(rest) + 1
+	}
+	return total
+}
+
+func (set ItemSet) String() string {
+	return string(set.Items())
+}
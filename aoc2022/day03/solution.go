@@ -1,62 +1,140 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
-	"strings"
+	"strconv"
+
+	"aoc2022/puzzle"
+)
+
+var (
+	compartmentCount = flag.Int("compartments", 2, "number of equally sized compartments in each rucksack")
+	groupSize        = flag.Int("group", 3, "number of elves sharing a badge")
 )
 
 func LetterScore(r rune) int {
+	score, err := itemScore(r)
+	if err != nil {
+		panic(err)
+	}
+	return score
+}
+
+func itemScore(r rune) (int, error) {
 	const a = int('a')
 	const A = int('A')
 	const alphabet = 26
 
 	lowercase := int(r) - a + 1
 	if lowercase <= alphabet && lowercase > 0 {
-		return lowercase
+		return lowercase, nil
 	}
 	uppercase := int(r) - A + 1
 	if uppercase <= alphabet && uppercase > 0 {
-		return uppercase + alphabet
+		return uppercase + alphabet, nil
 	}
-	panic(fmt.Sprintf("unsupported character: %q (ascii=%d, upper=%d, lower=%d)", r, int(r), uppercase, lowercase))
-	return 0
+	return 0, fmt.Errorf("unsupported character: %q (ascii=%d, upper=%d, lower=%d)", r, int(r), uppercase, lowercase)
 }
 
-func part1(filename string) {
-	var total int
-	for line := range ReadLines(filename) {
-		if len(line)%2 != 0 {
-			log.Fatalf("odd number of items in rucksack %q: %d", line, len(line))
+// Contents of each rucksack, one per line of input
+type Rucksacks []string
+
+func ReadRucksacks(filename string) (Rucksacks, error) {
+	var rucksacks Rucksacks
+	lines, err := puzzle.ReadLines(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+	for line := range lines.C {
+		_, err := NewItemSet(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", len(rucksacks)+1, err)
 		}
-		for _, r := range line[len(line)/2:] {
-			if strings.ContainsRune(line[:len(line)/2], r) {
-				total += LetterScore(r)
-				break
-			}
+		rucksacks = append(rucksacks, line)
+	}
+	err = lines.Close()
+	if err != nil {
+		return nil, err
+	}
+	return rucksacks, nil
+}
+
+func (r Rucksacks) Copy() Rucksacks {
+	return append(Rucksacks(nil), r...)
+}
+
+func part1(rucksacks Rucksacks) string {
+	var total int
+	for _, items := range rucksacks {
+		compartments, err := Compartments(items, *compartmentCount)
+		if err != nil {
+			log.Fatal(err)
 		}
+		total += Intersect(compartments...).Score()
 	}
-	fmt.Printf("Part 1 score: %d\n", total)
+	return strconv.Itoa(total)
 }
 
-func part2(filename string) {
-	group := make([]string, 3)
-	var total, index int
-	for line := range ReadLines(filename) {
-		group[index%len(group)] = line
-		index += 1
-		if index%len(group) == 0 {
-			total += GroupScore(group)
+func part2(rucksacks Rucksacks) string {
+	if *groupSize < 1 {
+		log.Fatalf("invalid group size: %d", *groupSize)
+	}
+	group := make([]ItemSet, 0, *groupSize)
+	var total int
+	for index, items := range rucksacks {
+		rucksack, err := NewItemSet(items)
+		if err != nil {
+			log.Fatalf("line %d: %v", index+1, err)
+		}
+		group = append(group, rucksack)
+		if len(group) < *groupSize {
+			continue
+		}
+		badge, err := Badge(group)
+		if err != nil {
+			log.Fatalf("group ending at line %d: %v", index+1, err)
 		}
+		total += LetterScore(badge)
+		group = group[:0]
 	}
-	fmt.Printf("Part 2 score: %d\n", total)
+	if len(group) != 0 {
+		log.Fatalf("incomplete group at the end of input: %d rucksacks out of %d", len(group), *groupSize)
+	}
+	return strconv.Itoa(total)
 }
 
-func GroupScore(group []string) int {
-	for _, r := range group[0] {
-		if strings.ContainsRune(group[1], r) && strings.ContainsRune(group[2], r) {
-			return LetterScore(r)
+// Split rucksack contents into equally sized compartments
+func Compartments(items string, count int) ([]ItemSet, error) {
+	if count < 1 {
+		return nil, fmt.Errorf("invalid number of compartments: %d", count)
+	}
+	if len(items)%count != 0 {
+		return nil, fmt.Errorf("can not split %d items in rucksack %q into %d compartments", len(items), items, count)
+	}
+	size := len(items) / count
+	compartments := make([]ItemSet, count)
+	for i := range compartments {
+		set, err := NewItemSet(items[i*size : (i+1)*size])
+		if err != nil {
+			return nil, err
 		}
+		compartments[i] = set
+	}
+	return compartments, nil
+}
+
+// Find the only item type carried by all elves in a group
+func Badge(group []ItemSet) (rune, error) {
+	shared := Intersect(group...)
+	switch shared.Len() {
+	case 0:
+		return 0, fmt.Errorf("no badge found")
+	case 1:
+		return shared.Items()[0], nil
+	default:
+		return 0, fmt.Errorf("several badge candidates: %s", shared)
 	}
-	panic(fmt.Sprintf("could not find a badge for group %q", group))
 }
@@ -24,3 +24,90 @@ func TestLetterScores(t *testing.T) {
 		}
 	}
 }
+
+func TestSample(t *testing.T) {
+	tests := []struct {
+		worker func(Rucksacks) string
+		result string
+	}{
+		{worker: part1, result: "157"},
+		{worker: part2, result: "70"},
+	}
+	input, err := ReadRucksacks("sample.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, test := range tests {
+		got := test.worker(input.Copy())
+		if got != test.result {
+			t.Errorf("sample: part %d expected %q, got %q", i+1, test.result, got)
+		}
+	}
+}
+
+func TestItemSet(t *testing.T) {
+	first, _ := NewItemSet("vJrwpWtwJgWr")
+	second, _ := NewItemSet("hcsFMMfFFhFp")
+	if got := Intersect(first, second).String(); got != "p" {
+		t.Errorf("shared items: want %q, got %q", "p", got)
+	}
+	set, _ := NewItemSet("zZaAbaa")
+	if got := set.String(); got != "abzAZ" {
+		t.Errorf("items: want %q, got %q", "abzAZ", got)
+	}
+	if got := set.Score(); got != 1+2+26+27+52 {
+		t.Errorf("score: want %d, got %d", 1+2+26+27+52, got)
+	}
+	if !set.Contains('Z') || set.Contains('c') || set.Contains('?') {
+		t.Errorf("Contains: wrong membership for %s", set)
+	}
+	if _, err := NewItemSet("ab1"); err == nil {
+		t.Errorf("want error for unsupported character, got nil")
+	}
+	if Intersect() != 0 {
+		t.Errorf("empty intersection must be empty")
+	}
+}
+
+func TestCompartments(t *testing.T) {
+	compartments, err := Compartments("abcXabcYabcZ", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Intersect(compartments...).String(); got != "abc" {
+		t.Errorf("shared items: want %q, got %q", "abc", got)
+	}
+	if _, err := Compartments("abcde", 2); err == nil {
+		t.Errorf("want error for uneven split, got nil")
+	}
+}
+
+func TestBadge(t *testing.T) {
+	tests := []struct {
+		group []string
+		badge rune
+		fail  bool
+	}{
+		{group: []string{"abc", "cde", "efc"}, badge: 'c'},
+		{group: []string{"abC", "Cd"}, badge: 'C'},
+		{group: []string{"xyz"}, fail: true},
+		{group: []string{"abc", "def"}, fail: true},
+		{group: []string{"abc", "abd", "abe"}, fail: true},
+	}
+	for _, test := range tests {
+		group := make([]ItemSet, len(test.group))
+		for i, items := range test.group {
+			group[i], _ = NewItemSet(items)
+		}
+		badge, err := Badge(group)
+		if test.fail {
+			if err == nil {
+				t.Errorf("%q: want error, got badge %q", test.group, badge)
+			}
+			continue
+		}
+		if err != nil || badge != test.badge {
+			t.Errorf("%q: want badge %q, got %q (%v)", test.group, test.badge, badge, err)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Static interval tree over all section assignments
+//
+// Assignments are sorted by start of range and viewed as an implicit
+// balanced binary search tree: the middle element of each slice is the root
+// of its subtree. Every node also remembers the biggest range end within its
+// subtree, which allows to skip subtrees that end before the query.
+type IntervalIndex struct {
+	items  []Assignment
+	maxEnd []int
+}
+
+func NewIntervalIndex(assignments []Assignment) *IntervalIndex {
+	index := &IntervalIndex{
+		items:  append([]Assignment(nil), assignments...),
+		maxEnd: make([]int, len(assignments)),
+	}
+	sort.SliceStable(index.items, func(i, j int) bool {
+		return index.items[i].Sections.Start < index.items[j].Sections.Start
+	})
+	index.build(0, len(index.items))
+	return index
+}
+
+func (index *IntervalIndex) build(lo, hi int) int {
+	if lo >= hi {
+		return 0
+	}
+	mid := (lo + hi) / 2
+	end := index.items[mid].Sections.End
+	if left := index.build(lo, mid); left > end {
+		end = left
+	}
+	if right := index.build(mid+1, hi); right > end {
+		end = right
+	}
+	index.maxEnd[mid] = end
+	return end
+}
+
+func (index *IntervalIndex) Len() int {
+	return len(index.items)
+}
+
+// Assignments that share at least one section with the given range
+func (index *IntervalIndex) Overlapping(query SectionRange) []Assignment {
+	var found []Assignment
+	index.search(0, len(index.items), query, &found)
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].Elf < found[j].Elf
+	})
+	return found
+}
+
+func (index *IntervalIndex) search(lo, hi int, query SectionRange, found *[]Assignment) {
+	if lo >= hi {
+		return
+	}
+	mid := (lo + hi) / 2
+	if index.maxEnd[mid] < query.Start {
+		return // everything in this subtree ends too early
+	}
+	index.search(lo, mid, query, found)
+	item := index.items[mid]
+	if item.Sections.Start > query.End {
+		return // this node and its right subtree start too late
+	}
+	if item.Sections.End >= query.Start {
+		*found = append(*found, item)
+	}
+	index.search(mid+1, hi, query, found)
+}
+
+// Assignments that include the given section
+func (index *IntervalIndex) Stab(section int) []Assignment {
+	return index.Overlapping(SectionRange{section, section})
+}
+
+// Maximum number of elves assigned to the same section, and all ranges of
+// sections where that maximum is reached
+func (index *IntervalIndex) MaxOverlap() (count int, where []SectionRange) {
+	type event struct {
+		section int
+		delta   int
+	}
+	events := make([]event, 0, 2*len(index.items))
+	for _, item := range index.items {
+		events = append(events, event{item.Sections.Start, 1}, event{item.Sections.End + 1, -1})
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].section < events[j].section
+	})
+	var current int
+	for i := 0; i < len(events); {
+		section := events[i].section
+		for ; i < len(events) && events[i].section == section; i++ {
+			current += events[i].delta
+		}
+		if current == 0 || current < count {
+			continue
+		}
+		if current > count {
+			count = current
+			where = where[:0]
+		}
+		// Range lasts until the next event, which always exists while current > 0
+		next := SectionRange{section, events[i].section - 1}
+		if len(where) > 0 && where[len(where)-1].End+1 == next.Start {
+			where[len(where)-1].End = next.End
+		} else {
+			where = append(where, next)
+		}
+	}
+	return count, where
+}
+
+// First and last sections assigned to anyone
+func (index *IntervalIndex) Bounds() (bounds SectionRange, ok bool) {
+	if len(index.items) == 0 {
+		return bounds, false
+	}
+	bounds = SectionRange{index.items[0].Sections.Start, index.maxEnd[len(index.items)/2]}
+	return bounds, true
+}
+
+// Gaps between assigned sections within Bounds
+func (index *IntervalIndex) Uncovered() []SectionRange {
+	var gaps []SectionRange
+	var covered int // last section covered by items seen so far
+	for i, item := range index.items {
+		if i > 0 && item.Sections.Start > covered+1 {
+			gaps = append(gaps, SectionRange{covered + 1, item.Sections.Start - 1})
+		}
+		if i == 0 || item.Sections.End > covered {
+			covered = item.Sections.End
+		}
+	}
+	return gaps
+}
+
+// Answer a question about section assignments:
+//
+//	overlap SECTION     elves assigned to the section
+//	overlap START-END   elves assigned to any section within range
+//	max                 maximum number of elves assigned to the same section
+//	uncovered           sections nobody is assigned to
+func query(assignments Assignments, question string) (string, error) {
+	index := NewIntervalIndex(assignments)
+	words := strings.Fields(question)
+	if len(words) == 0 {
+		return "", fmt.Errorf("empty query")
+	}
+	switch {
+	case words[0] == "overlap" && len(words) == 2:
+		var sections SectionRange
+		if strings.Contains(words[1], "-") {
+			err := sections.Parse(words[1])
+			if err != nil {
+				return "", err
+			}
+		} else {
+			section, err := strconv.Atoi(words[1])
+			if err != nil {
+				return "", fmt.Errorf("invalid section: %w", err)
+			}
+			sections = SectionRange{section, section}
+		}
+		found := index.Overlapping(sections)
+		lines := make([]string, 0, len(found)+1)
+		lines = append(lines, fmt.Sprintf("%d elves assigned to sections %v", len(found), sections))
+		for _, assignment := range found {
+			lines = append(lines, assignment.String())
+		}
+		return strings.Join(lines, "\n"), nil
+	case words[0] == "max" && len(words) == 1:
+		count, where := index.MaxOverlap()
+		return fmt.Sprintf("%d elves at sections %s", count, joinRanges(where)), nil
+	case words[0] == "uncovered" && len(words) == 1:
+		bounds, ok := index.Bounds()
+		if !ok {
+			return "no sections assigned", nil
+		}
+		gaps := index.Uncovered()
+		if len(gaps) == 0 {
+			return fmt.Sprintf("all sections within %v are covered", bounds), nil
+		}
+		return fmt.Sprintf("sections within %v nobody covers: %s", bounds, joinRanges(gaps)), nil
+	default:
+		return "", fmt.Errorf("unsupported query: %q (expected: overlap SECTION, overlap START-END, max, uncovered)", question)
+	}
+}
+
+func joinRanges(ranges []SectionRange) string {
+	chunks := make([]string, len(ranges))
+	for i, r := range ranges {
+		chunks[i] = r.String()
+	}
+	return strings.Join(chunks, ", ")
+}
@@ -2,9 +2,10 @@ package main
 
 import (
 	"fmt"
-	"log"
 	"strconv"
 	"strings"
+
+	"aoc2022/puzzle"
 )
 
 type SectionRange struct {
@@ -12,20 +13,24 @@ type SectionRange struct {
 	End   int
 }
 
-func (sr *SectionRange) Parse(input string) {
+func (sr *SectionRange) Parse(input string) error {
 	boundaries := strings.Split(input, "-")
 	if len(boundaries) != 2 {
-		log.Fatalf("invalid range definition: %q", input)
+		return fmt.Errorf("invalid range definition: %q", input)
 	}
 	var err error
 	sr.Start, err = strconv.Atoi(boundaries[0])
 	if err != nil {
-		log.Fatalf("could not parse lower boundary: %v", err)
+		return fmt.Errorf("could not parse lower boundary: %w", err)
 	}
 	sr.End, err = strconv.Atoi(boundaries[1])
 	if err != nil {
-		log.Fatalf("could not parse upper boundary: %v", err)
+		return fmt.Errorf("could not parse upper boundary: %w", err)
+	}
+	if sr.Start > sr.End {
+		return fmt.Errorf("empty range: %q", input)
 	}
+	return nil
 }
 
 func (this *SectionRange) Contains(other *SectionRange) bool {
@@ -36,39 +41,81 @@ func (this *SectionRange) Overlaps(other *SectionRange) bool {
 	return this.Contains(other) || (this.Start >= other.Start && this.Start <= other.End) || (this.End >= other.Start && this.End <= other.End)
 }
 
-func part1(filename string) {
-	var first, second *SectionRange
-	first, second = new(SectionRange), new(SectionRange)
-	var answer int
-	for line := range ReadLines(filename) {
+func (sr SectionRange) String() string {
+	return fmt.Sprintf("%d-%d", sr.Start, sr.End)
+}
+
+// Sections assigned to a single elf
+//
+// Elves are numbered from 1 in order of appearance, two per input line.
+type Assignment struct {
+	Elf      int
+	Sections SectionRange
+}
+
+func (a Assignment) String() string {
+	return fmt.Sprintf("elf #%d (%v)", a.Elf, a.Sections)
+}
+
+type Assignments []Assignment
+
+func ReadAssignments(filename string) (Assignments, error) {
+	var assignments Assignments
+	var lineNo int
+	lines, err := puzzle.ReadLines(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+	for line := range lines.C {
+		lineNo++
 		elves := strings.Split(line, ",")
 		if len(elves) != 2 {
-			log.Fatalf("invalid input line: %q", line)
+			return nil, fmt.Errorf("line %d: invalid input line: %q", lineNo, line)
 		}
-		first.Parse(elves[0])
-		second.Parse(elves[1])
-		if first.Contains(second) || second.Contains(first) {
-			answer += 1
+		for _, elf := range elves {
+			assignment := Assignment{Elf: len(assignments) + 1}
+			err := assignment.Sections.Parse(elf)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			assignments = append(assignments, assignment)
 		}
 	}
-	fmt.Printf("Part 1 answer: %d\n", answer)
+	err = lines.Close()
+	if err != nil {
+		return nil, err
+	}
+	return assignments, nil
+}
+
+func (a Assignments) Copy() Assignments {
+	return append(Assignments(nil), a...)
 }
 
-func part2(filename string) {
-	var first, second *SectionRange
-	first, second = new(SectionRange), new(SectionRange)
+// Call function for each pair of elves sharing an input line
+func (a Assignments) Pairs(callback func(first, second *SectionRange)) {
+	for i := 0; i+1 < len(a); i += 2 {
+		callback(&a[i].Sections, &a[i+1].Sections)
+	}
+}
+
+func part1(assignments Assignments) string {
 	var answer int
-	for line := range ReadLines(filename) {
-		elves := strings.Split(line, ",")
-		if len(elves) != 2 {
-			log.Fatalf("invalid input line: %q", line)
+	assignments.Pairs(func(first, second *SectionRange) {
+		if first.Contains(second) || second.Contains(first) {
+			answer += 1
 		}
-		first.Parse(elves[0])
-		second.Parse(elves[1])
+	})
+	return strconv.Itoa(answer)
+}
+
+func part2(assignments Assignments) string {
+	var answer int
+	assignments.Pairs(func(first, second *SectionRange) {
 		if first.Overlaps(second) {
-			//log.Println(line)
 			answer += 1
 		}
-	}
-	fmt.Printf("Part 2 answer: %d\n", answer)
+	})
+	return strconv.Itoa(answer)
 }
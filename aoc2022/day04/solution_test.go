@@ -0,0 +1,83 @@
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestSample(t *testing.T) {
+	assignments, err := ReadAssignments("sample.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		worker func(Assignments) string
+		result string
+	}{
+		{worker: part1, result: "2"},
+		{worker: part2, result: "4"},
+	}
+	for i, test := range tests {
+		got := test.worker(assignments.Copy())
+		if got != test.result {
+			t.Errorf("sample: part %d expected %q, got %q", i+1, test.result, got)
+		}
+	}
+}
+
+func TestOverlapping(t *testing.T) {
+	random := rand.New(rand.NewSource(4))
+	for round := 0; round < 100; round++ {
+		assignments := make([]Assignment, random.Intn(30))
+		for i := range assignments {
+			start := 1 + random.Intn(50)
+			assignments[i] = Assignment{
+				Elf:      i + 1,
+				Sections: SectionRange{start, start + random.Intn(10)},
+			}
+		}
+		index := NewIntervalIndex(assignments)
+		for i := 0; i < 20; i++ {
+			start := random.Intn(65)
+			query := SectionRange{start, start + random.Intn(5)}
+			var want []Assignment
+			for _, a := range assignments {
+				if a.Sections.Overlaps(&query) || query.Overlaps(&a.Sections) {
+					want = append(want, a)
+				}
+			}
+			got := index.Overlapping(query)
+			if len(want) == 0 && len(got) == 0 {
+				continue
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("%v in %v: want %v, got %v", query, assignments, want, got)
+			}
+		}
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	index := NewIntervalIndex([]Assignment{
+		{1, SectionRange{10, 12}},
+		{2, SectionRange{1, 3}},
+		{3, SectionRange{2, 4}},
+		{4, SectionRange{6, 7}},
+		{5, SectionRange{11, 15}},
+		{6, SectionRange{3, 3}},
+	})
+	count, where := index.MaxOverlap()
+	if want := []SectionRange{{3, 3}}; count != 3 || !reflect.DeepEqual(where, want) {
+		t.Errorf("max overlap: want 3 at %v, got %d at %v", want, count, where)
+	}
+	if want := []SectionRange{{5, 5}, {8, 9}}; !reflect.DeepEqual(index.Uncovered(), want) {
+		t.Errorf("uncovered: want %v, got %v", want, index.Uncovered())
+	}
+	if bounds, _ := index.Bounds(); bounds != (SectionRange{1, 15}) {
+		t.Errorf("bounds: want 1-15, got %v", bounds)
+	}
+	if got := len(index.Stab(11)); got != 2 {
+		t.Errorf("section 11: want 2 elves, got %d", got)
+	}
+}
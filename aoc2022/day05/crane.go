@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+)
+
+// Crane model decides how crates travel between stacks
+type Crane interface {
+	Move(stacks StackGroup, move Move) error
+
+	// Moves that revert the given one when executed by the same crane
+	Inverse(move Move) []Move
+
+	String() string
+}
+
+func reverse(move Move) Move {
+	return Move{Boxes: move.Boxes, From: move.To, To: move.From}
+}
+
+// Lifts one crate at a time, reversing the order of moved crates
+type CrateMover9000 struct{}
+
+func (CrateMover9000) Move(stacks StackGroup, move Move) error {
+	return move.Apply(stacks)
+}
+
+func (CrateMover9000) Inverse(move Move) []Move {
+	return []Move{reverse(move)}
+}
+
+func (CrateMover9000) String() string {
+	return "CrateMover 9000"
+}
+
+// Lifts any number of crates at once, retaining their order
+type CrateMover9001 struct{}
+
+func (CrateMover9001) Move(stacks StackGroup, move Move) error {
+	return move.ApplyBatch(stacks)
+}
+
+func (CrateMover9001) Inverse(move Move) []Move {
+	return []Move{reverse(move)}
+}
+
+func (CrateMover9001) String() string {
+	return "CrateMover 9001"
+}
+
+// Lifts up to Capacity crates at once
+//
+// Crates within each lift retain their order, lifts are stacked on top of
+// each other. Capacity of 1 is the same as CrateMover 9000, capacity that
+// exceeds the largest move is the same as CrateMover 9001.
+type CrateMover struct {
+	Capacity int
+}
+
+func (crane CrateMover) Move(stacks StackGroup, move Move) error {
+	if crane.Capacity < 1 {
+		return fmt.Errorf("%v: invalid capacity", crane)
+	}
+	for left := move.Boxes; left > 0; left -= crane.Capacity {
+		lift := move
+		if left < crane.Capacity {
+			lift.Boxes = left
+		} else {
+			lift.Boxes = crane.Capacity
+		}
+		err := lift.ApplyBatch(stacks)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Lifts are taken back one by one starting from the last (possibly
+// incomplete) one
+func (crane CrateMover) Inverse(move Move) []Move {
+	if move.Boxes == 0 || crane.Capacity < 1 {
+		return nil
+	}
+	var inverse []Move
+	back := reverse(move)
+	if last := move.Boxes % crane.Capacity; last != 0 {
+		back.Boxes = last
+		inverse = append(inverse, back)
+	}
+	back.Boxes = crane.Capacity
+	for i := 0; i < move.Boxes/crane.Capacity; i++ {
+		inverse = append(inverse, back)
+	}
+	return inverse
+}
+
+func (crane CrateMover) String() string {
+	return fmt.Sprintf("CrateMover (up to %d crates at once)", crane.Capacity)
+}
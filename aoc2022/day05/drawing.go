@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"unicode"
+)
+
+// Column span of a label or a crate within stack drawing
+type span struct {
+	start, end int // inclusive, in runes
+}
+
+func (s span) overlaps(other span) bool {
+	return s.start <= other.end && other.start <= s.end
+}
+
+// Parse initial stack configuration
+//
+// The last line of drawing contains stack labels which must be numbered
+// sequentially from 1. Crates are written in square brackets and belong to
+// the stack whose label is located under them, so both labels and crate
+// names may be longer than one character as long as columns are aligned:
+//
+//	    [DD]
+//	[N] [CC]
+//	[Z] [MM] [P]
+//	 1   2    3
+func ParseDrawing(drawing []string) (StackGroup, error) {
+	if len(drawing) == 0 {
+		return nil, fmt.Errorf("empty stack drawing")
+	}
+	labels, err := parseLabels(drawing[len(drawing)-1])
+	if err != nil {
+		return nil, fmt.Errorf("stack labels: %w", err)
+	}
+	stacks := NewStackGroup(len(labels))
+	for height := 0; height < len(drawing)-1; height++ {
+		row := len(drawing) - 2 - height
+		crates, err := parseCrates(drawing[row])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", row+1, err)
+		}
+		for _, crate := range crates {
+			var target []int
+			for index, label := range labels {
+				if label.overlaps(crate.span) {
+					target = append(target, index)
+				}
+			}
+			switch {
+			case len(target) == 0:
+				return nil, fmt.Errorf("line %d: crate %q is not above any stack label", row+1, crate.name)
+			case len(target) > 1:
+				return nil, fmt.Errorf("line %d: crate %q is above several stack labels: %d", row+1, crate.name, len(target))
+			}
+			stack := stacks[target[0]]
+			if len(*stack) != height {
+				return nil, fmt.Errorf("line %d: crate %q is floating above stack %d", row+1, crate.name, target[0]+1)
+			}
+			stack.Push(crate.name)
+		}
+	}
+	return stacks, nil
+}
+
+func parseLabels(line string) ([]span, error) {
+	var labels []span
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		if unicode.IsSpace(runes[i]) {
+			continue
+		}
+		start := i
+		for i < len(runes) && !unicode.IsSpace(runes[i]) {
+			i++
+		}
+		label := string(runes[start:i])
+		number, err := strconv.Atoi(label)
+		if err != nil {
+			return nil, fmt.Errorf("invalid label %q", label)
+		}
+		if number != len(labels)+1 {
+			return nil, fmt.Errorf("expected label %d, got %d", len(labels)+1, number)
+		}
+		labels = append(labels, span{start, i - 1})
+	}
+	if len(labels) == 0 {
+		return nil, fmt.Errorf("no labels found in %q", line)
+	}
+	return labels, nil
+}
+
+type drawnCrate struct {
+	name Crate
+	span span
+}
+
+func parseCrates(line string) ([]drawnCrate, error) {
+	var crates []drawnCrate
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		switch {
+		case unicode.IsSpace(runes[i]):
+			continue
+		case runes[i] != '[':
+			return nil, fmt.Errorf("unexpected character at column %d: %q", i+1, runes[i])
+		}
+		start := i
+		for i < len(runes) && runes[i] != ']' {
+			i++
+		}
+		if i == len(runes) {
+			return nil, fmt.Errorf("unterminated crate at column %d", start+1)
+		}
+		name := string(runes[start+1 : i])
+		if len(name) == 0 {
+			return nil, fmt.Errorf("unnamed crate at column %d", start+1)
+		}
+		crates = append(crates, drawnCrate{Crate(name), span{start, i}})
+	}
+	return crates, nil
+}
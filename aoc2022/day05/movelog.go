@@ -0,0 +1,206 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Rearrangement procedure that can be stepped through in both directions
+type MoveLog struct {
+	Stacks StackGroup
+	crane  Crane
+	moves  []Move
+	step   int // number of moves applied to Stacks
+}
+
+func NewMoveLog(stacks StackGroup, crane Crane, moves []Move) *MoveLog {
+	return &MoveLog{
+		Stacks: stacks,
+		crane:  crane,
+		moves:  append([]Move(nil), moves...),
+	}
+}
+
+// Number of moves applied so far
+func (l *MoveLog) Step() int {
+	return l.step
+}
+
+// Total number of recorded moves
+func (l *MoveLog) Len() int {
+	return len(l.moves)
+}
+
+// Apply a new move, forgetting all undone moves
+func (l *MoveLog) Do(move Move) error {
+	err := l.crane.Move(l.Stacks, move)
+	if err != nil {
+		return err
+	}
+	l.moves = append(l.moves[:l.step], move)
+	l.step++
+	return nil
+}
+
+// Apply next recorded move
+func (l *MoveLog) Redo() error {
+	if l.step == len(l.moves) {
+		return fmt.Errorf("nothing to redo")
+	}
+	err := l.crane.Move(l.Stacks, l.moves[l.step])
+	if err != nil {
+		return fmt.Errorf("step %d: %w", l.step+1, err)
+	}
+	l.step++
+	return nil
+}
+
+// Revert last applied move
+func (l *MoveLog) Undo() error {
+	if l.step == 0 {
+		return fmt.Errorf("nothing to undo")
+	}
+	for _, move := range l.crane.Inverse(l.moves[l.step-1]) {
+		err := l.crane.Move(l.Stacks, move)
+		if err != nil {
+			return fmt.Errorf("undo step %d: %w", l.step, err)
+		}
+	}
+	l.step--
+	return nil
+}
+
+// Undo or replay moves until given step is reached
+func (l *MoveLog) Seek(step int) error {
+	if step < 0 || step > len(l.moves) {
+		return fmt.Errorf("step %d is out of range [0, %d]", step, len(l.moves))
+	}
+	for l.step < step {
+		err := l.Redo()
+		if err != nil {
+			return err
+		}
+	}
+	for l.step > step {
+		err := l.Undo()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Moves that restore initial configuration from current step
+func (l *MoveLog) Inverse() []Move {
+	var inverse []Move
+	for step := l.step - 1; step >= 0; step-- {
+		inverse = append(inverse, l.crane.Inverse(l.moves[step])...)
+	}
+	return inverse
+}
+
+// Draw stacks in the same format as puzzle input
+func (sg StackGroup) Render() string {
+	widths := make([]int, len(sg))
+	var height int
+	for index, stack := range sg {
+		widths[index] = 3
+		for _, crate := range *stack {
+			if w := len([]rune(crate)) + 2; w > widths[index] {
+				widths[index] = w
+			}
+		}
+		if len(*stack) > height {
+			height = len(*stack)
+		}
+	}
+	lines := make([]string, 0, height+1)
+	var line strings.Builder
+	for row := height - 1; row >= 0; row-- {
+		line.Reset()
+		for index, stack := range sg {
+			if index > 0 {
+				line.WriteRune(' ')
+			}
+			var cell string
+			if row < len(*stack) {
+				cell = fmt.Sprintf("[%s]", (*stack)[row])
+			}
+			fmt.Fprintf(&line, "%-*s", widths[index], cell)
+		}
+		lines = append(lines, strings.TrimRight(line.String(), " "))
+	}
+	line.Reset()
+	for index := range sg {
+		if index > 0 {
+			line.WriteRune(' ')
+		}
+		label := strconv.Itoa(index + 1)
+		padding := (widths[index] - len(label)) / 2
+		if padding < 0 {
+			padding = 0
+		}
+		fmt.Fprintf(&line, "%*s%-*s", padding, "", widths[index]-padding, label)
+	}
+	lines = append(lines, strings.TrimRight(line.String(), " "))
+	return strings.Join(lines, "\n")
+}
+
+var craneModel = flag.String("crane", "9000", "crane model for -query: 9000, 9001 or custom (see -capacity)")
+
+func selectCrane(model string) (Crane, error) {
+	switch model {
+	case "9000":
+		return CrateMover9000{}, nil
+	case "9001":
+		return CrateMover9001{}, nil
+	case "custom":
+		if *craneCapacity < 1 {
+			return nil, fmt.Errorf("invalid crane capacity: %d", *craneCapacity)
+		}
+		return CrateMover{Capacity: *craneCapacity}, nil
+	default:
+		return nil, fmt.Errorf("unknown crane model: %s", model)
+	}
+}
+
+// Answer a question about rearrangement procedure:
+//
+//	top             crates on top of each stack after all moves
+//	render STEP     stack drawing after given number of moves
+//	inverse STEP    moves that restore initial configuration after given step
+//
+// Crane model is chosen with -crane.
+func query(cargo *Cargo, question string) (string, error) {
+	crane, err := selectCrane(*craneModel)
+	if err != nil {
+		return "", err
+	}
+	words := strings.Fields(question)
+	if len(words) == 1 && words[0] == "top" {
+		return cargo.Rearrange(crane)
+	}
+	if len(words) != 2 || (words[0] != "render" && words[0] != "inverse") {
+		return "", fmt.Errorf("unsupported query: %q (expected: top, render STEP, inverse STEP)", question)
+	}
+	step, err := strconv.Atoi(words[1])
+	if err != nil {
+		return "", fmt.Errorf("invalid step: %w", err)
+	}
+	history := NewMoveLog(cargo.Stacks, crane, cargo.Moves)
+	err = history.Seek(step)
+	if err != nil {
+		return "", err
+	}
+	if words[0] == "render" {
+		return history.Stacks.Render(), nil
+	}
+	inverse := history.Inverse()
+	lines := make([]string, len(inverse))
+	for i, move := range inverse {
+		lines[i] = move.String()
+	}
+	return strings.Join(lines, "\n"), nil
+}
@@ -1,30 +1,31 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"strconv"
 	"strings"
+
+	"aoc2022/puzzle"
 )
 
-type Stack []rune
+// Crate label, may be longer than one character
+type Crate string
 
-func (s *Stack) Push(item rune) {
-	if item == ' ' {
-		return
-	}
+type Stack []Crate
+
+func (s *Stack) Push(item Crate) {
 	*s = append(*s, item)
 }
 
-func (s *Stack) PushN(items []rune) {
-	for _, item := range items {
-		s.Push(item)
-	}
+func (s *Stack) PushN(items []Crate) {
+	*s = append(*s, items...)
 }
 
-func (s *Stack) Pop() (item rune, ok bool) {
+func (s *Stack) Pop() (item Crate, ok bool) {
 	if len(*s) == 0 {
-		return ' ', false
+		return "", false
 	}
 	index := len(*s) - 1
 	item = (*s)[index]
@@ -32,8 +33,8 @@ func (s *Stack) Pop() (item rune, ok bool) {
 	return item, true
 }
 
-func (s *Stack) PopN(length int) (items []rune, ok bool) {
-	if len(*s) < length {
+func (s *Stack) PopN(length int) (items []Crate, ok bool) {
+	if length < 0 || len(*s) < length {
 		return items, false
 	}
 	index := len(*s) - length
@@ -42,9 +43,9 @@ func (s *Stack) PopN(length int) (items []rune, ok bool) {
 	return items, true
 }
 
-func (s *Stack) Top() rune {
+func (s *Stack) Top() Crate {
 	if len(*s) == 0 {
-		return ' '
+		return " "
 	}
 	return (*s)[len(*s)-1]
 }
@@ -67,116 +68,172 @@ func NewStackGroup(length int) StackGroup {
 	return stacks
 }
 
+func (sg StackGroup) Copy() StackGroup {
+	stacks := make(StackGroup, len(sg))
+	for index, stack := range sg {
+		clone := append(Stack(nil), *stack...)
+		stacks[index] = &clone
+	}
+	return stacks
+}
+
 type Move struct {
 	Boxes int
 	From  int
 	To    int
 }
 
-func (m *Move) Parse(input string) {
+func (m *Move) Parse(input string) error {
 	words := strings.Fields(input)
 	if len(words) != 6 {
-		log.Fatalf("incorrect command: %q", input)
+		return fmt.Errorf("incorrect command: %q", input)
 	}
 	command, from, to := words[0], words[2], words[4]
 	if command != "move" || from != "from" || to != "to" {
-		log.Fatalf("invalid command words: %q", input)
+		return fmt.Errorf("invalid command words: %q", input)
 	}
 	var err error
 	m.Boxes, err = strconv.Atoi(words[1])
-	if err != nil {
-		log.Fatalf("invalid number of boxes: %q", words[1])
+	if err != nil || m.Boxes < 0 {
+		return fmt.Errorf("invalid number of boxes: %q", words[1])
 	}
 	m.From, err = strconv.Atoi(words[3])
 	if err != nil {
-		log.Fatalf("invalid from address: %q", words[3])
+		return fmt.Errorf("invalid from address: %q", words[3])
 	}
 	m.To, err = strconv.Atoi(words[5])
 	if err != nil {
-		log.Fatalf("invalid destination address: %q", words[5])
+		return fmt.Errorf("invalid destination address: %q", words[5])
+	}
+	return nil
+}
+
+func (m Move) String() string {
+	return fmt.Sprintf("move %d from %d to %d", m.Boxes, m.From, m.To)
+}
+
+// Source and destination stacks for the move
+func (m *Move) stacks(stacks StackGroup) (source, destination *Stack, err error) {
+	if m.From < 1 || m.From > len(stacks) {
+		return nil, nil, fmt.Errorf("%v: no such stack: %d", m, m.From)
+	}
+	if m.To < 1 || m.To > len(stacks) {
+		return nil, nil, fmt.Errorf("%v: no such stack: %d", m, m.To)
 	}
+	return stacks[m.From-1], stacks[m.To-1], nil
 }
 
-func (m *Move) Apply(stacks StackGroup) {
-	var box rune
-	var ok bool
-	var source, destination *Stack
+// Move boxes one by one
+func (m *Move) Apply(stacks StackGroup) error {
+	source, destination, err := m.stacks(stacks)
+	if err != nil {
+		return err
+	}
 	for i := 0; i < m.Boxes; i++ {
-		source = stacks[m.From-1]
-		destination = stacks[m.To-1]
-		box, ok = source.Pop()
+		box, ok := source.Pop()
 		if !ok {
-			log.Fatalf("could not pop a box from %v", source)
+			return fmt.Errorf("%v: could not pop a box from %v", m, source)
 		}
 		destination.Push(box)
 	}
+	return nil
 }
 
-func (m *Move) ApplyBatch(stacks StackGroup) {
-	var boxes []rune
-	var ok bool
-	var source, destination *Stack
-	source = stacks[m.From-1]
-	destination = stacks[m.To-1]
-	boxes, ok = source.PopN(m.Boxes)
+// Move all boxes at once
+func (m *Move) ApplyBatch(stacks StackGroup) error {
+	source, destination, err := m.stacks(stacks)
+	if err != nil {
+		return err
+	}
+	boxes, ok := source.PopN(m.Boxes)
 	if !ok {
-		log.Fatalf("could not pop %d boxes from %v", m.Boxes, source)
+		return fmt.Errorf("%v: could not pop %d boxes from %v", m, m.Boxes, source)
 	}
 	destination.PushN(boxes)
+	return nil
 }
 
-func solution(filename string, part int) {
-	readMoves := false
-	initial := make([]string, 0)
-	var stacks StackGroup
-	var move Move
-	for line := range ReadLines(filename) {
-		if len(line) == 0 {
-			continue
-		}
-		if readMoves {
-			move.Parse(line)
-			switch part {
-			case 1:
-				move.Apply(stacks)
-			case 2:
-				move.ApplyBatch(stacks)
-			default:
-				log.Fatalf("invalid puzzle part: %d", part)
+// Initial stacks and the rearrangement procedure
+type Cargo struct {
+	Stacks StackGroup
+	Moves  []Move
+}
+
+func ReadCargo(filename string) (*Cargo, error) {
+	cargo := new(Cargo)
+	var drawing []string
+	var lineNo int
+	lines, err := puzzle.ReadLines(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+	for line := range lines.C {
+		lineNo++
+		if cargo.Stacks == nil {
+			if len(line) == 0 {
+				var err error
+				cargo.Stacks, err = ParseDrawing(drawing)
+				if err != nil {
+					return nil, err
+				}
+				continue
 			}
+			drawing = append(drawing, line)
 			continue
 		}
-		if strings.HasPrefix(line, " 1   2") {
-			//log.Println("Parsing initial stack configuration")
-			stackLabels := strings.Fields(line)
-			if len(stackLabels) > 9 {
-				log.Fatalf("this implementation assumes single character stack labels")
-			}
-			stacks = NewStackGroup(len(stackLabels))
-			for row := len(initial) - 1; row >= 0; row -= 1 {
-				line = initial[row]
-				//log.Printf("parsing line %q", line)
-				for stackNum, stack := range stacks {
-					index := stackNum*4 + 1
-					runes := []rune(line)
-					if index < len(runes) {
-						//log.Printf("Pushing %c to stack %v", runes[index], stack)
-						stack.Push(runes[index])
-					}
-				}
-			}
-			readMoves = true
+		if len(line) == 0 {
 			continue
 		}
-		initial = append(initial, line)
+		var move Move
+		err := move.Parse(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		cargo.Moves = append(cargo.Moves, move)
+	}
+	err = lines.Close()
+	if err != nil {
+		return nil, err
+	}
+	if cargo.Stacks == nil {
+		return nil, fmt.Errorf("stack drawing must be followed by an empty line")
+	}
+	return cargo, nil
+}
+
+func (c *Cargo) Copy() *Cargo {
+	return &Cargo{
+		Stacks: c.Stacks.Copy(),
+		Moves:  append([]Move(nil), c.Moves...),
+	}
+}
+
+// Execute rearrangement procedure and report crates on top of each stack
+func (c *Cargo) Rearrange(crane Crane) (string, error) {
+	for i, move := range c.Moves {
+		err := crane.Move(c.Stacks, move)
+		if err != nil {
+			return "", fmt.Errorf("%s, step %d: %w", crane, i+1, err)
+		}
+	}
+	return c.Stacks.Top(), nil
+}
+
+var craneCapacity = flag.Int("capacity", 3, "max number of crates lifted at once by custom crane (see -crane)")
+
+func solution(cargo *Cargo, crane Crane) string {
+	top, err := cargo.Rearrange(crane)
+	if err != nil {
+		log.Fatal(err)
 	}
-	fmt.Printf("Part %d result: %q\n", part, stacks.Top())
+	return top
 }
 
-func part1(filename string) {
-	solution(filename, 1)
+func part1(cargo *Cargo) string {
+	return solution(cargo, CrateMover9000{})
 }
 
-func part2(filename string) {
-	solution(filename, 2)
+func part2(cargo *Cargo) string {
+	return solution(cargo, CrateMover9001{})
 }
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSample(t *testing.T) {
+	cargo, err := ReadCargo("sample.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		crane  Crane
+		result string
+	}{
+		{crane: CrateMover9000{}, result: "CMZ"},
+		{crane: CrateMover9001{}, result: "MCD"},
+		{crane: CrateMover{Capacity: 1}, result: "CMZ"},
+		{crane: CrateMover{Capacity: 2}, result: "MCZ"},
+		{crane: CrateMover{Capacity: 3}, result: "MCD"},
+	}
+	for _, test := range tests {
+		got, err := cargo.Copy().Rearrange(test.crane)
+		if err != nil {
+			t.Errorf("%v: %v", test.crane, err)
+			continue
+		}
+		if got != test.result {
+			t.Errorf("%v: expected %q, got %q", test.crane, test.result, got)
+		}
+	}
+}
+
+func TestParseDrawing(t *testing.T) {
+	stacks, err := ParseDrawing([]string{
+		"                                        [Kx]",
+		"[A] [B]                                 [Ky]",
+		"[C] [D] [E] [F] [G] [H] [I] [J] [K] [L] [Kz]",
+		" 1   2   3   4   5   6   7   8   9  10   11",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stacks) != 11 {
+		t.Fatalf("want 11 stacks, got %d", len(stacks))
+	}
+	if got := stacks.Top(); got != "ABEFGHIJKLKx" {
+		t.Errorf("top crates: want %q, got %q", "ABEFGHIJKLKx", got)
+	}
+	if got := len(*stacks[10]); got != 3 {
+		t.Errorf("stack 11: want 3 crates, got %d", got)
+	}
+
+	invalid := [][]string{
+		{},
+		{"[A]", " 2"},
+		{"[A]", " x"},
+		{"[A] [B", " 1   2"},
+		{"[A] ?", " 1"},
+		{"    [A]", "[B]", " 1   2"},
+		{"[AAAAAAA]", " 1   2"},
+		{"     [A]", " 1"},
+	}
+	for _, drawing := range invalid {
+		if _, err := ParseDrawing(drawing); err == nil {
+			t.Errorf("%q: want error, got nil", drawing)
+		}
+	}
+}
+
+func TestInvalidMove(t *testing.T) {
+	stacks := NewStackGroup(2)
+	stacks[0].Push("A")
+	for _, move := range []Move{{1, 1, 3}, {1, 0, 1}, {2, 1, 2}} {
+		for _, crane := range []Crane{CrateMover9000{}, CrateMover9001{}, CrateMover{Capacity: 2}} {
+			if err := crane.Move(stacks.Copy(), move); err == nil {
+				t.Errorf("%v, %v: want error, got nil", crane, move)
+			}
+		}
+	}
+}
+
+func TestMoveLog(t *testing.T) {
+	cargo, err := ReadCargo("input.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	initial := cargo.Stacks.Render()
+	for _, crane := range []Crane{CrateMover9000{}, CrateMover9001{}, CrateMover{Capacity: 2}, CrateMover{Capacity: 5}} {
+		want, err := cargo.Copy().Rearrange(crane)
+		if err != nil {
+			t.Fatal(err)
+		}
+		history := NewMoveLog(cargo.Stacks.Copy(), crane, cargo.Moves)
+		for _, step := range []int{history.Len(), 10, 0, history.Len() / 2, history.Len()} {
+			err = history.Seek(step)
+			if err != nil {
+				t.Fatalf("%v: seek to step %d: %v", crane, step, err)
+			}
+		}
+		if got := history.Stacks.Top(); got != want {
+			t.Errorf("%v: replayed result %q, want %q", crane, got, want)
+		}
+		for _, move := range history.Inverse() {
+			err = crane.Move(history.Stacks, move)
+			if err != nil {
+				t.Fatalf("%v: inverse move %v: %v", crane, move, err)
+			}
+		}
+		if got := history.Stacks.Render(); got != initial {
+			t.Errorf("%v: inverse moves did not restore initial configuration:\n%s", crane, got)
+		}
+		if err = history.Seek(history.Len() + 1); err == nil {
+			t.Errorf("%v: seek beyond last step: want error, got nil", crane)
+		}
+	}
+}
+
+func TestQuery(t *testing.T) {
+	cargo, err := ReadCargo("sample.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(model string, capacity int) {
+		*craneModel, *craneCapacity = model, capacity
+	}(*craneModel, *craneCapacity)
+	tests := []struct {
+		model    string
+		capacity int
+		result   string
+	}{
+		{"9000", 0, "CMZ"},
+		{"9001", 0, "MCD"},
+		{"custom", 2, "MCZ"},
+		{"custom", 1, "CMZ"},
+	}
+	for _, test := range tests {
+		*craneModel, *craneCapacity = test.model, test.capacity
+		got, err := query(cargo.Copy(), "top")
+		if err != nil || got != test.result {
+			t.Errorf("crane %s (capacity %d): want %q, got %q (%v)", test.model, test.capacity, test.result, got, err)
+		}
+	}
+	*craneModel, *craneCapacity = "custom", 0
+	if _, err := query(cargo.Copy(), "top"); err == nil {
+		t.Errorf("crane without capacity accepted")
+	}
+	*craneModel = "9000"
+	for _, invalid := range []string{"", "top 1", "render", "bottom"} {
+		if _, err := query(cargo.Copy(), invalid); err == nil {
+			t.Errorf("%q: want error, got nil", invalid)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	sample := []string{
+		"    [D]",
+		"[N] [C]",
+		"[Z] [M] [P]",
+		" 1   2   3",
+	}
+	stacks, err := ParseDrawing(sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := stacks.Render(), strings.Join(sample, "\n"); got != want {
+		t.Errorf("sample drawing:\n%s\nwant:\n%s", got, want)
+	}
+
+	stacks = NewStackGroup(12)
+	stacks[0].PushN([]Crate{"A", "Long", "B"})
+	stacks[10].Push("Z")
+	stacks[11].PushN([]Crate{"XY", "Q"})
+	parsed, err := ParseDrawing(strings.Split(stacks.Render(), "\n"))
+	if err != nil {
+		t.Fatalf("%v:\n%s", err, stacks.Render())
+	}
+	if !reflect.DeepEqual(parsed, stacks) {
+		t.Errorf("round trip failed:\n%s", stacks.Render())
+	}
+}
@@ -1,71 +1,249 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
 	"strconv"
 )
 
-type SlidingWindow struct {
-	items []rune
-	size  int
+var allMarkers = flag.Bool("all", false, "print offsets of all markers to stdout, report the number of markers as result")
+
+// Streaming detector of markers: sequences of distinct bytes of given size
+//
+// Each byte is processed in constant time: the detector keeps count of each
+// byte value within current window along with the number of values that are
+// repeated in it.
+type MarkerDetector struct {
+	size    int
+	window  []byte // ring buffer
+	slot    int    // position of the oldest byte in window
+	counts  [256]int
+	repeats int
+	offset  int64
 }
 
-func (sw *SlidingWindow) Push(value rune) {
-	sw.items = append(sw.items, value)
-	if len(sw.items) > sw.size {
-		sw.items = sw.items[len(sw.items)-sw.size:]
+func NewMarkerDetector(size int) *MarkerDetector {
+	if size < 1 {
+		panic(fmt.Sprintf("invalid marker size: %d", size))
+	}
+	return &MarkerDetector{
+		size:   size,
+		window: make([]byte, size),
 	}
 }
 
-func (sw *SlidingWindow) Contains(value rune) bool {
-	for _, item := range sw.items {
-		if item == value {
-			return true
+// Process next byte, return true if it completes a marker
+func (d *MarkerDetector) Push(b byte) bool {
+	full := d.offset >= int64(d.size)
+	if full {
+		old := d.window[d.slot]
+		d.counts[old]--
+		if d.counts[old] == 1 {
+			d.repeats--
 		}
 	}
-	return false
+	d.window[d.slot] = b
+	d.counts[b]++
+	if d.counts[b] == 2 {
+		d.repeats++
+	}
+	d.slot++
+	if d.slot == d.size {
+		d.slot = 0
+	}
+	d.offset++
+	return d.repeats == 0 && (full || d.offset == int64(d.size))
 }
 
-func (sw *SlidingWindow) Full() bool {
-	return len(sw.items) == sw.size
+// Number of bytes processed so far
+func (d *MarkerDetector) Offset() int64 {
+	return d.offset
 }
 
-func (sw *SlidingWindow) Unique() bool {
-	itemset := make(map[rune]bool)
-	for _, item := range sw.items {
-		if itemset[item] {
-			return false
+// Call function with offset of each marker found in stream
+//
+// Offset is the number of bytes read up to and including the end of marker.
+// Returning an error from callback stops the scan.
+func ScanMarkers(input io.Reader, size int, callback func(offset int64) error) error {
+	detector := NewMarkerDetector(size)
+	buffer := make([]byte, 1<<16)
+	for {
+		n, err := input.Read(buffer)
+		for _, b := range buffer[:n] {
+			if !detector.Push(b) {
+				continue
+			}
+			if err := callback(detector.Offset()); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
 		}
-		itemset[item] = true
+		if err != nil {
+			return err
+		}
+	}
+}
+
+var errStop = errors.New("stop scanning")
+
+// Offset of the first marker in stream, -1 if there is none
+func FindMarker(input io.Reader, size int) (int64, error) {
+	var found int64 = -1
+	err := ScanMarkers(input, size, func(offset int64) error {
+		found = offset
+		return errStop
+	})
+	if err != nil && err != errStop {
+		return -1, err
 	}
-	return len(itemset) == len(sw.items)
+	return found, nil
 }
 
-func LocateStartOfPacket(input <-chan rune) (position int) {
-	return LocateEndOfMark(input, 4)
+// Puzzle input: name of the capture file, "-" means stdin
+//
+// Captures may be huge, so they are never loaded into memory. Each part
+// opens the capture and scans it as a stream.
+type Capture string
+
+// Check that capture file exists, reading is left for Open
+func ReadCapture(filename string) (Capture, error) {
+	if filename != "-" {
+		_, err := os.Stat(filename)
+		if err != nil {
+			return "", err
+		}
+	}
+	return Capture(filename), nil
 }
 
-func LocateStartOfMessage(input <-chan rune) (position int) {
-	return LocateEndOfMark(input, 14)
+func (c Capture) Copy() Capture {
+	return c
 }
 
-func LocateEndOfMark(input <-chan rune, markSize int) (position int) {
-	window := SlidingWindow{size: markSize}
-	for char := range input {
-		position += 1
-		window.Push(char)
-		if window.Full() && window.Unique() {
-			return position
+var stdinOpened bool
+
+// Open capture for reading its datastream
+//
+// Stdin can not be rewound, so it may be opened only once.
+func (c Capture) Open() (*Datastream, error) {
+	file := os.Stdin
+	if c != "-" {
+		var err error
+		file, err = os.Open(string(c))
+		if err != nil {
+			return nil, err
 		}
+	} else if stdinOpened {
+		return nil, errors.New("stdin can be scanned only once, choose one puzzle part with -part")
+	} else {
+		stdinOpened = true
+	}
+	return &Datastream{
+		file:    file,
+		input:   bufio.NewReaderSize(file, 1<<16),
+		lineEnd: -1,
+	}, nil
+}
+
+// Stream of bytes from the communication device
+//
+// Datastream is a single line, terminator at its end is not a part of the
+// data and could otherwise complete a spurious marker. Line breaks are
+// checked while reading, so any data after a line break is reported as an
+// error only when the stream is read that far.
+type Datastream struct {
+	file    *os.File
+	input   *bufio.Reader
+	offset  int64
+	lineEnd int64 // offset of the first line terminator, -1 if not reached yet
+}
+
+func (d *Datastream) Read(p []byte) (int, error) {
+	if d.lineEnd >= 0 {
+		return 0, d.skipTerminators()
+	}
+	n, err := d.input.Read(p)
+	index := bytes.IndexAny(p[:n], "\r\n")
+	if index < 0 {
+		d.offset += int64(n)
+		return n, err
+	}
+	d.offset += int64(index)
+	d.lineEnd = d.offset
+	if len(bytes.Trim(p[index:n], "\r\n")) > 0 {
+		return index, d.errLineBreak()
+	}
+	if err == nil {
+		err = d.skipTerminators()
+	}
+	return index, err
+}
+
+// Consume line terminators at the end of stream
+func (d *Datastream) skipTerminators() error {
+	for {
+		b, err := d.input.ReadByte()
+		if err != nil {
+			return err
+		}
+		if b != '\r' && b != '\n' {
+			return d.errLineBreak()
+		}
+	}
+}
+
+func (d *Datastream) errLineBreak() error {
+	return fmt.Errorf("unexpected line break at offset %d: datastream must be a single line", d.lineEnd)
+}
+
+// Release capture file, stdin is left open
+func (d *Datastream) Close() error {
+	if d.file == os.Stdin {
+		return nil
+	}
+	return d.file.Close()
+}
+
+func solution(capture Capture, markSize int) string {
+	input, err := capture.Open()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer input.Close()
+	if !*allMarkers {
+		result, err := FindMarker(input, markSize)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return strconv.FormatInt(result, 10)
+	}
+
+	output := bufio.NewWriter(os.Stdout)
+	defer output.Flush()
+	var count int
+	err = ScanMarkers(input, markSize, func(offset int64) error {
+		count++
+		_, err := fmt.Fprintln(output, offset)
+		return err
+	})
+	if err != nil {
+		log.Fatal(err)
 	}
-	return -1
+	return strconv.Itoa(count)
 }
 
-func part1(filename string) string {
-	result := LocateStartOfPacket(ReadChars(filename))
-	return strconv.Itoa(result)
+func part1(capture Capture) string {
+	return solution(capture, 4)
 }
 
-func part2(filename string) string {
-	result := LocateStartOfMessage(ReadChars(filename))
-	return strconv.Itoa(result)
+func part2(capture Capture) string {
+	return solution(capture, 14)
 }
@@ -1,50 +1,143 @@
 package main
 
 import (
+	"bytes"
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
-)
 
-func runesChannel(input string) (output chan rune) {
-	output = make(chan rune)
-	go func() {
-		for _, r := range input {
-			output <- r
-		}
-		close(output)
-	}()
-	return output
-}
+	"aoc2022/puzzle/puzzletest"
+)
 
 func TestSamplesPart1(t *testing.T) {
-	samples := map[string]int{
+	samples := map[string]int64{
 		"mjqjpqmgbljsphdztnvjfqwrcgsmlb":    7,
 		"bvwbjplbgvbhsrlpgdmjqwftvncz":      5,
 		"nppdvjthqldpwncqszvftbrmjlhg":      6,
 		"nznrnfrfntjfmvfwmzdfjlvtqnbhcprsg": 10,
 		"zcfzfwzzqfrljwzlrfnpqdbhtmscgvjw":  11,
 	}
-	var got int
 	for input, expected := range samples {
-		got = LocateStartOfPacket(runesChannel(input))
-		if got != expected {
-			t.Errorf("incorrect result for %q: expected %d, got %d", input, expected, got)
+		got, err := FindMarker(strings.NewReader(input), 4)
+		if err != nil || got != expected {
+			t.Errorf("incorrect result for %q: expected %d, got %d (%v)", input, expected, got, err)
 		}
 	}
 }
 
 func TestSamplesPart2(t *testing.T) {
-	samples := map[string]int{
+	samples := map[string]int64{
 		"mjqjpqmgbljsphdztnvjfqwrcgsmlb":    19,
 		"bvwbjplbgvbhsrlpgdmjqwftvncz":      23,
 		"nppdvjthqldpwncqszvftbrmjlhg":      23,
 		"nznrnfrfntjfmvfwmzdfjlvtqnbhcprsg": 29,
 		"zcfzfwzzqfrljwzlrfnpqdbhtmscgvjw":  26,
 	}
-	var got int
 	for input, expected := range samples {
-		got = LocateStartOfMessage(runesChannel(input))
-		if got != expected {
-			t.Errorf("incorrect result for %q: expected %d, got %d", input, expected, got)
+		got, err := FindMarker(strings.NewReader(input), 14)
+		if err != nil || got != expected {
+			t.Errorf("incorrect result for %q: expected %d, got %d (%v)", input, expected, got, err)
+		}
+	}
+}
+
+// Quadratic reference implementation
+func naiveMarkers(input []byte, size int) (offsets []int64) {
+	for end := size; end <= len(input); end++ {
+		seen := make(map[byte]bool)
+		for _, b := range input[end-size : end] {
+			seen[b] = true
+		}
+		if len(seen) == size {
+			offsets = append(offsets, int64(end))
+		}
+	}
+	return offsets
+}
+
+func TestScanMarkers(t *testing.T) {
+	random := rand.New(rand.NewSource(6))
+	for round := 0; round < 200; round++ {
+		input := make([]byte, random.Intn(200))
+		alphabet := 1 + random.Intn(20)
+		for i := range input {
+			input[i] = byte('a' + random.Intn(alphabet))
+		}
+		size := 1 + random.Intn(8)
+		var got []int64
+		err := ScanMarkers(bytes.NewReader(input), size, func(offset int64) error {
+			got = append(got, offset)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := naiveMarkers(input, size)
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("%q, size %d: want %v, got %v", input, size, want, got)
+		}
+		first, err := FindMarker(bytes.NewReader(input), size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(want) == 0 && first != -1 || len(want) > 0 && first != want[0] {
+			t.Fatalf("%q, size %d: want first marker at %v, got %d", input, size, want, first)
+		}
+	}
+}
+
+func scanCapture(t *testing.T, content string) (markers int, err error) {
+	capture, err := ReadCapture(puzzletest.WriteInput(t, content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	input, err := capture.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer input.Close()
+	err = ScanMarkers(input, 4, func(int64) error {
+		markers++
+		return nil
+	})
+	return markers, err
+}
+
+// Line terminator must not complete a marker: "bcd\n" has four distinct bytes
+func TestDatastream(t *testing.T) {
+	for _, content := range []string{"abcd", "abcd\n", "abcd\r\n", "abcd\n\n"} {
+		count, err := scanCapture(t, content)
+		if err != nil || count != 1 {
+			t.Errorf("%q: want a single marker, got %d (%v)", content, count, err)
+		}
+	}
+	// line break is detected while scanning, even beyond the read buffer
+	long := strings.Repeat("aaaa", 1<<15) + "\n"
+	for _, content := range []string{"abcd\nefgh\n", "ab\r\ncd", long + "\nx"} {
+		_, err := scanCapture(t, content)
+		if err == nil || !strings.Contains(err.Error(), "unexpected line break") {
+			t.Errorf("%.10q...: want error for several lines, got %v", content, err)
+		}
+	}
+	if _, err := ReadCapture(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Errorf("missing capture accepted")
+	}
+}
+
+func BenchmarkScanMarkers(b *testing.B) {
+	input := make([]byte, 1<<20)
+	random := rand.New(rand.NewSource(6))
+	for i := range input {
+		input[i] = byte('a' + random.Intn(26))
+	}
+	b.SetBytes(int64(len(input)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err := ScanMarkers(bytes.NewReader(input), 14, func(int64) error { return nil })
+		if err != nil {
+			b.Fatal(err)
 		}
 	}
 }
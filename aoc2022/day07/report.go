@@ -0,0 +1,277 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Children in alphabetical order
+func (fi *FSItem) SortedChildren() []*FSItem {
+	children := make([]*FSItem, 0, len(fi.Children))
+	for _, child := range fi.Children {
+		children = append(children, child)
+	}
+	sort.Slice(children, func(i, j int) bool {
+		return children[i].Name < children[j].Name
+	})
+	return children
+}
+
+// Visit item and everything below it, parents before children
+//
+// Depth of the starting item is zero. Returning false from callback skips
+// the items below the current one.
+func (fi *FSItem) Walk(callback func(item *FSItem, depth int) bool) {
+	fi.walk(0, callback)
+}
+
+func (fi *FSItem) walk(depth int, callback func(*FSItem, int) bool) {
+	if !callback(fi, depth) {
+		return
+	}
+	for _, child := range fi.SortedChildren() {
+		child.walk(depth+1, callback)
+	}
+}
+
+// Render directory tree in the same way as `tree` utility does
+func (fi *FSItem) Tree() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (%d)\n", fi.Name, fi.Size())
+	fi.renderTree(&b, "")
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func (fi *FSItem) renderTree(b *strings.Builder, indent string) {
+	children := fi.SortedChildren()
+	for i, child := range children {
+		branch, next := "├── ", "│   "
+		if i == len(children)-1 {
+			branch, next = "└── ", "    "
+		}
+		name := child.Name
+		if child.IsDir() {
+			name += "/"
+		}
+		fmt.Fprintf(b, "%s%s%s (%d)\n", indent, branch, name, child.Size())
+		if child.IsDir() {
+			child.renderTree(b, indent+next)
+		}
+	}
+}
+
+// Render directory sizes in the same way as `du` utility does: children
+// before parents, one directory per line
+func (fi *FSItem) Du(human bool) string {
+	var lines []string
+	var visit func(item *FSItem)
+	visit = func(item *FSItem) {
+		for _, child := range item.SortedChildren() {
+			if child.IsDir() {
+				visit(child)
+			}
+		}
+		size := strconv.Itoa(item.Size())
+		if human {
+			size = HumanSize(item.Size())
+		}
+		lines = append(lines, fmt.Sprintf("%s\t%s", size, item.Path()))
+	}
+	visit(fi)
+	return strings.Join(lines, "\n")
+}
+
+// Size with binary unit suffix, rounded up like `du -h` does
+func HumanSize(size int) string {
+	const units = "KMGTPE"
+	if size < 1024 {
+		return strconv.Itoa(size)
+	}
+	value := float64(size)
+	var unit int
+	for unit = -1; value >= 1024 && unit < len(units)-1; unit++ {
+		value /= 1024
+	}
+	if value < 10 {
+		value = math.Ceil(value*10) / 10
+		if value < 10 {
+			return fmt.Sprintf("%.1f%c", value, units[unit])
+		}
+	}
+	return fmt.Sprintf("%.0f%c", math.Ceil(value), units[unit])
+}
+
+// Filter for items of the file system
+type FindFilter struct {
+	Type     *FSItemType
+	Name     string // glob pattern
+	MinDepth int
+	MaxDepth int  // negative means unlimited
+	MinSize  *int // nil means unlimited
+	MaxSize  *int // nil means unlimited
+}
+
+// Parse filter expression similar to arguments of `find` utility:
+//
+//	-type f|d          files or directories only
+//	-name GLOB         name matches shell pattern
+//	-size [+-]N[kMG]   size is greater than, less than or exactly N
+//	-mindepth N        at least N levels below starting point
+//	-maxdepth N        at most N levels below starting point
+//
+// All conditions must be satisfied at the same time.
+func ParseFindFilter(args []string) (filter FindFilter, err error) {
+	filter = FindFilter{MaxDepth: -1}
+	for i := 0; i < len(args); i += 2 {
+		option := args[i]
+		if i+1 >= len(args) {
+			return filter, fmt.Errorf("missing argument for %s", option)
+		}
+		value := strings.Trim(args[i+1], `'"`)
+		switch option {
+		case "-type":
+			var t FSItemType
+			switch value {
+			case "f":
+				t = File
+			case "d":
+				t = Directory
+			default:
+				return filter, fmt.Errorf("unknown type: %s", value)
+			}
+			filter.Type = &t
+		case "-name":
+			if _, err := path.Match(value, ""); err != nil {
+				return filter, fmt.Errorf("invalid pattern %q: %w", value, err)
+			}
+			filter.Name = value
+		case "-size":
+			err = filter.parseSize(value)
+		case "-mindepth":
+			filter.MinDepth, err = strconv.Atoi(value)
+		case "-maxdepth":
+			filter.MaxDepth, err = strconv.Atoi(value)
+		default:
+			return filter, fmt.Errorf("unknown option: %s", option)
+		}
+		if err != nil {
+			return filter, fmt.Errorf("%s: %w", option, err)
+		}
+	}
+	return filter, nil
+}
+
+func (filter *FindFilter) parseSize(value string) error {
+	var sign byte
+	if len(value) > 0 && (value[0] == '+' || value[0] == '-') {
+		sign, value = value[0], value[1:]
+	}
+	multiplier := 1
+	if len(value) > 0 {
+		if index := strings.IndexByte("kMG", value[len(value)-1]); index >= 0 {
+			multiplier = 1 << (10 * (index + 1))
+			value = value[:len(value)-1]
+		}
+	}
+	size, err := strconv.Atoi(value)
+	if err != nil || size < 0 {
+		return fmt.Errorf("invalid size: %s", value)
+	}
+	size *= multiplier
+	min, max := size, size
+	switch sign {
+	case '+':
+		min++
+		filter.MinSize = &min
+	case '-':
+		max--
+		filter.MaxSize = &max
+	default:
+		filter.MinSize, filter.MaxSize = &min, &max
+	}
+	return nil
+}
+
+func (filter *FindFilter) Match(item *FSItem, depth int) bool {
+	switch {
+	case depth < filter.MinDepth:
+		return false
+	case filter.MaxDepth >= 0 && depth > filter.MaxDepth:
+		return false
+	case filter.Type != nil && item.Type != *filter.Type:
+		return false
+	case filter.MinSize != nil && item.Size() < *filter.MinSize:
+		return false
+	case filter.MaxSize != nil && item.Size() > *filter.MaxSize:
+		return false
+	}
+	if filter.Name != "" {
+		matched, _ := path.Match(filter.Name, item.Name)
+		return matched
+	}
+	return true
+}
+
+// Items below current one (including itself) that pass the filter
+func (fi *FSItem) Find(filter FindFilter) (found []*FSItem) {
+	fi.Walk(func(item *FSItem, depth int) bool {
+		if filter.Match(item, depth) {
+			found = append(found, item)
+		}
+		return filter.MaxDepth < 0 || depth < filter.MaxDepth
+	})
+	return found
+}
+
+// Answer a question about reconstructed file system:
+//
+//	tree [PATH]               directory tree with sizes
+//	du [-h] [PATH]            size of each directory
+//	find [PATH] [FILTER...]   items matching filter, see ParseFindFilter
+func query(root *FSItem, question string) (string, error) {
+	words := strings.Fields(question)
+	if len(words) == 0 {
+		return "", fmt.Errorf("empty query")
+	}
+	command, args := words[0], words[1:]
+	var human bool
+	if command == "du" && len(args) > 0 && args[0] == "-h" {
+		human, args = true, args[1:]
+	}
+	start := root
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		shell := Shell{root: root, CurrentDir: root}
+		var err error
+		start, err = shell.Resolve(args[0])
+		if err != nil {
+			return "", err
+		}
+		args = args[1:]
+	}
+	switch {
+	case command == "tree" && len(args) == 0:
+		return start.Tree(), nil
+	case command == "du" && len(args) == 0:
+		if !start.IsDir() {
+			return "", fmt.Errorf("not a directory: %s", start.Path())
+		}
+		return start.Du(human), nil
+	case command == "find":
+		filter, err := ParseFindFilter(args)
+		if err != nil {
+			return "", err
+		}
+		found := start.Find(filter)
+		lines := make([]string, len(found))
+		for i, item := range found {
+			lines[i] = fmt.Sprintf("%d\t%s", item.Size(), item.Path())
+		}
+		return strings.Join(lines, "\n"), nil
+	default:
+		return "", fmt.Errorf("unsupported query: %q (expected: tree [PATH], du [-h] [PATH], find [PATH] [FILTER...])", question)
+	}
+}
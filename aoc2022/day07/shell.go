@@ -0,0 +1,340 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"aoc2022/puzzle"
+)
+
+type Command struct {
+	Name string
+	Args []string
+}
+
+const CommandPrompt = "$ "
+
+// Supported commands and their number of arguments
+var commandArgs = map[string]struct{ min, max int }{
+	"cd":    {1, 1},
+	"ls":    {0, 0},
+	"mkdir": {1, 1},
+	"rm":    {1, 2}, // rm [-r] PATH
+	"mv":    {2, 2}, // mv SOURCE DESTINATION
+	"touch": {1, 2}, // touch PATH [SIZE]
+	"cat":   {1, 1}, // followed by file contents which define file size
+}
+
+func (c *Command) Parse(line string) error {
+	words := strings.Fields(line)
+	if len(words) < 2 {
+		return fmt.Errorf("command line too short: %s", line)
+	}
+	if words[0] != strings.TrimSpace(CommandPrompt) {
+		return fmt.Errorf("command must start with prompt %q: %s", CommandPrompt, line)
+	}
+	c.Name = words[1]
+	if len(words) > 2 {
+		c.Args = words[2:]
+	} else {
+		c.Args = []string{}
+	}
+
+	expected, ok := commandArgs[c.Name]
+	if !ok {
+		return fmt.Errorf("unknown command: %s", c.Name)
+	}
+	if len(c.Args) < expected.min || len(c.Args) > expected.max {
+		want := strconv.Itoa(expected.min)
+		if expected.max != expected.min {
+			want = fmt.Sprintf("%d to %d", expected.min, expected.max)
+		}
+		return fmt.Errorf(
+			"%q: invalid number of arguments for %s: expected %s, got %d %q",
+			line,
+			c.Name,
+			want,
+			len(c.Args),
+			c.Args,
+		)
+	}
+	return nil
+}
+
+type Shell struct {
+	Running    Command
+	CurrentDir *FSItem
+	root       *FSItem
+}
+
+func (s *Shell) Execute(cmd *Command) (err error) {
+	s.Running = Command{}
+	switch cmd.Name {
+	default:
+		return fmt.Errorf("Execute() not implemented for command: %s", cmd.Name)
+	case "cd":
+		var dest *FSItem
+		dest, err = s.Resolve(cmd.Args[0])
+		if err != nil {
+			return fmt.Errorf("%s: %w", cmd.Name, err)
+		}
+		if !dest.IsDir() {
+			return fmt.Errorf("%s: not a directory: %s", cmd.Name, cmd.Args[0])
+		}
+		s.CurrentDir = dest
+	case "ls":
+		s.Running = *cmd
+	case "mkdir":
+		err = s.create(cmd.Args[0], &FSItem{Type: Directory})
+	case "touch":
+		err = s.touch(cmd.Args)
+	case "rm":
+		err = s.remove(cmd.Args)
+	case "mv":
+		err = s.move(cmd.Args[0], cmd.Args[1])
+	case "cat":
+		var file *FSItem
+		file, err = s.Resolve(cmd.Args[0])
+		if err == nil && file.IsDir() {
+			err = fmt.Errorf("is a directory: %s", cmd.Args[0])
+		}
+		if err == nil {
+			file.SetFileSize(0)
+			s.Running = *cmd
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd.Name, err)
+	}
+	return nil
+}
+
+// Find item by absolute or relative path
+func (s *Shell) Resolve(path string) (*FSItem, error) {
+	item := s.CurrentDir
+	if strings.HasPrefix(path, "/") {
+		item = s.root
+	}
+	for _, name := range strings.Split(path, "/") {
+		switch name {
+		case "", ".":
+			continue
+		case "..":
+			if item.Parent == nil {
+				return nil, fmt.Errorf("can not go up from %s", item.Path())
+			}
+			item = item.Parent
+		default:
+			if !item.IsDir() {
+				return nil, fmt.Errorf("not a directory: %s", item.Path())
+			}
+			child, ok := item.Children[name]
+			if !ok {
+				return nil, fmt.Errorf("no such file or directory: %s", path)
+			}
+			item = child
+		}
+	}
+	return item, nil
+}
+
+// Find parent directory for a new item
+func (s *Shell) resolveParent(path string) (parent *FSItem, name string, err error) {
+	trimmed := strings.TrimRight(path, "/")
+	index := strings.LastIndex(trimmed, "/")
+	dir, name := trimmed[:index+1], trimmed[index+1:]
+	if name == "" || name == "." || name == ".." {
+		return nil, "", fmt.Errorf("invalid name: %s", path)
+	}
+	if dir == "" {
+		dir = "."
+	}
+	parent, err = s.Resolve(dir)
+	if err != nil {
+		return nil, "", err
+	}
+	if !parent.IsDir() {
+		return nil, "", fmt.Errorf("not a directory: %s", dir)
+	}
+	return parent, name, nil
+}
+
+func (s *Shell) create(path string, item *FSItem) error {
+	parent, name, err := s.resolveParent(path)
+	if err != nil {
+		return err
+	}
+	if _, exists := parent.Children[name]; exists {
+		return fmt.Errorf("already exists: %s", path)
+	}
+	item.Name = name
+	parent.Add(item)
+	return nil
+}
+
+func (s *Shell) touch(args []string) error {
+	var size int
+	if len(args) == 2 {
+		var err error
+		size, err = strconv.Atoi(args[1])
+		if err != nil || size < 0 {
+			return fmt.Errorf("invalid file size: %s", args[1])
+		}
+	}
+	item, err := s.Resolve(args[0])
+	if err != nil {
+		return s.create(args[0], &FSItem{Type: File, fileSize: size})
+	}
+	if item.IsDir() {
+		return fmt.Errorf("is a directory: %s", args[0])
+	}
+	if len(args) == 2 {
+		item.SetFileSize(size)
+	}
+	return nil
+}
+
+func (s *Shell) remove(args []string) error {
+	path, recursive := args[len(args)-1], false
+	if len(args) == 2 {
+		if args[0] != "-r" {
+			return fmt.Errorf("unsupported option: %s", args[0])
+		}
+		recursive = true
+	}
+	item, err := s.Resolve(path)
+	if err != nil {
+		return err
+	}
+	if item.IsDir() && !recursive {
+		return fmt.Errorf("is a directory: %s", path)
+	}
+	if item == s.root || item.Contains(s.CurrentDir) {
+		return fmt.Errorf("can not remove current directory or its parent: %s", path)
+	}
+	item.Parent.Remove(item.Name)
+	return nil
+}
+
+// Rename item, or move it into destination if that is an existing directory
+func (s *Shell) move(source, destination string) error {
+	item, err := s.Resolve(source)
+	if err != nil {
+		return err
+	}
+	if item == s.root {
+		return fmt.Errorf("can not move root directory")
+	}
+	var parent *FSItem
+	var name string
+	if target, err := s.Resolve(destination); err == nil && target.IsDir() {
+		parent, name = target, item.Name
+	} else {
+		parent, name, err = s.resolveParent(destination)
+		if err != nil {
+			return err
+		}
+	}
+	if item.Contains(parent) {
+		return fmt.Errorf("can not move %s into itself", source)
+	}
+	if existing, ok := parent.Children[name]; ok {
+		if existing == item {
+			return nil
+		}
+		if existing.IsDir() {
+			return fmt.Errorf("destination is a directory: %s", existing.Path())
+		}
+		parent.Remove(name)
+	}
+	item.Parent.Remove(item.Name)
+	item.Name = name
+	parent.Add(item)
+	return nil
+}
+
+// Consume a line of output for the running command
+func (s *Shell) ParseOutput(line string) (err error) {
+	switch s.Running.Name {
+	case "ls":
+		return s.ParseLs(line)
+	case "cat":
+		file, err := s.Resolve(s.Running.Args[0])
+		if err != nil {
+			return err
+		}
+		file.SetFileSize(file.fileSize + len(line) + 1) // including newline
+		return nil
+	default:
+		return fmt.Errorf("unexpected output")
+	}
+}
+
+func (s *Shell) ParseLs(line string) (err error) {
+	meta, name, ok := strings.Cut(line, " ")
+	if !ok {
+		return fmt.Errorf("invalid ls output: %s", line)
+	}
+	item := &FSItem{Name: name}
+	switch meta {
+	case "dir":
+		item.Type = Directory
+	default:
+		item.Type = File
+		item.fileSize, err = strconv.Atoi(meta)
+		if err != nil {
+			return fmt.Errorf("unable to parse file size for %s: %w", item.Name, err)
+		}
+	}
+	if existing, ok := s.CurrentDir.Children[item.Name]; ok && existing.Type == item.Type {
+		if !existing.IsDir() {
+			existing.SetFileSize(item.fileSize)
+		}
+		return nil // keep contents of directories listed again
+	}
+	s.CurrentDir.Add(item)
+	return nil
+}
+
+func ParseShellOutput(filename string) (root *FSItem, err error) {
+	lines, err := puzzle.ReadLines(filename)
+	if err != nil {
+		return nil, err
+	}
+	root, err = Replay(lines.C)
+	if closeErr := lines.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// Reconstruct file system from recorded shell session
+func Replay(lines <-chan string) (root *FSItem, err error) {
+	root = &FSItem{Name: "/", Type: Directory}
+	shell := Shell{
+		CurrentDir: root,
+		root:       root,
+	}
+	command := &Command{}
+
+	var lineNo uint
+	for line := range lines {
+		lineNo++
+		if strings.HasPrefix(line, CommandPrompt) {
+			err = command.Parse(line)
+			if err == nil {
+				err = shell.Execute(command)
+			}
+		} else {
+			err = shell.ParseOutput(line)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+	}
+	return root, nil
+}
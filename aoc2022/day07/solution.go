@@ -8,55 +8,17 @@ import (
 	"strings"
 )
 
-type Command struct {
-	Name string
-	Args []string
-}
-
-const CommandPrompt = "$ "
-
-func (c *Command) Parse(line string) error {
-	words := strings.Fields(line)
-	if len(words) < 2 {
-		return fmt.Errorf("command line too short: %s", line)
-	}
-	if words[0] != strings.TrimSpace(CommandPrompt) {
-		return fmt.Errorf("command must start with prompt %q: %s", CommandPrompt, line)
-	}
-	c.Name = words[1]
-	if len(words) > 2 {
-		c.Args = words[2:]
-	} else {
-		c.Args = []string{}
-	}
-
-	nargs := map[string]int{
-		"cd": 1,
-		"ls": 0,
-	}
-	expected, ok := nargs[c.Name]
-	if !ok {
-		return fmt.Errorf("cannot validate args for unknown command: %s", c.Name)
-	}
-	if expected != len(c.Args) {
-		return fmt.Errorf(
-			"%q: invalid number of arguments for %s: expected %d, got %d %q",
-			line,
-			c.Name,
-			expected,
-			len(c.Args),
-			c.Args,
-		)
-	}
-	return nil
-}
-
 type FSItem struct {
 	Name     string
 	Type     FSItemType
 	Children map[string]*FSItem
 	Parent   *FSItem
 	fileSize int
+
+	// Directory size is calculated on first request and cached until
+	// something below the directory changes
+	size       int
+	sizeCached bool
 }
 
 type FSItemType uint8
@@ -75,109 +37,101 @@ func (fi *FSItem) Size() (size int) {
 	case File:
 		return fi.fileSize
 	case Directory:
+		if fi.sizeCached {
+			return fi.size
+		}
 		for _, child := range fi.Children {
 			size += child.Size()
 		}
+		fi.size, fi.sizeCached = size, true
 		return size
 	default:
 		panic(fmt.Sprintf("Size() not implemented for file type %d", fi.Type))
 	}
-	return size
 }
 
-type Shell struct {
-	Running    Command
-	CurrentDir *FSItem
-	root       *FSItem
+func (fi *FSItem) SetFileSize(size int) {
+	fi.fileSize = size
+	fi.Parent.invalidate()
 }
 
-func (s *Shell) Execute(cmd *Command) error {
-	switch cmd.Name {
-	default:
-		return fmt.Errorf("Execute() not implemented for command: %s", cmd.Name)
-	case "cd":
-		var dest *FSItem
-		var arg string
-		arg = cmd.Args[0]
-		switch arg {
-		case "/":
-			dest = s.root
-		case "..":
-			dest = s.CurrentDir.Parent
-			if dest == nil {
-				return fmt.Errorf("can not go up from %s", s.CurrentDir.Name)
-			}
-		default:
-			var ok bool
-			dest, ok = s.CurrentDir.Children[cmd.Args[0]]
-			if !ok {
-				return fmt.Errorf("%s: destination does not exist: %s", cmd.Name, cmd.Args[0])
-			}
-		}
-		s.CurrentDir = dest
-		s.Running = Command{}
-	case "ls":
-		s.Running = *cmd
+// Drop cached sizes of the directory and all its parents
+//
+// Walking up may stop early at a directory without cached size: all its
+// parents can not have cached size either.
+func (fi *FSItem) invalidate() {
+	for item := fi; item != nil && item.sizeCached; item = item.Parent {
+		item.sizeCached = false
 	}
-	return nil
 }
 
-func (s *Shell) ParseLs(line string) (err error) {
-	meta, name, ok := strings.Cut(line, " ")
-	if !ok {
-		return fmt.Errorf("invalid ls output: %s", line)
+// Deep copy of the file system tree below current item
+//
+// The copy is detached: its root has no parent even if the original had one.
+func (fi *FSItem) Copy() *FSItem {
+	return fi.copyInto(nil)
+}
+
+func (fi *FSItem) copyInto(parent *FSItem) *FSItem {
+	clone := &FSItem{
+		Name:     fi.Name,
+		Type:     fi.Type,
+		Parent:   parent,
+		fileSize: fi.fileSize,
+
+		size:       fi.size,
+		sizeCached: fi.sizeCached,
 	}
-	item := &FSItem{Name: name}
-	switch meta {
-	case "dir":
-		item.Type = Directory
-		item.Parent = s.CurrentDir
-	default:
-		item.Type = File
-		item.fileSize, err = strconv.Atoi(meta)
-		if err != nil {
-			return fmt.Errorf("unable to parse file size for %s: %w", item.Name, err)
+	if fi.Children != nil {
+		clone.Children = make(map[string]*FSItem, len(fi.Children))
+		for name, child := range fi.Children {
+			clone.Children[name] = child.copyInto(clone)
 		}
 	}
-	children := &s.CurrentDir.Children
-	if *children == nil {
-		*children = make(map[string]*FSItem)
+	return clone
+}
+
+// Attach child item to current directory
+func (fi *FSItem) Add(child *FSItem) {
+	if fi.Children == nil {
+		fi.Children = make(map[string]*FSItem)
 	}
-	(*children)[item.Name] = item
-	return nil
+	fi.Children[child.Name] = child
+	child.Parent = fi
+	fi.invalidate()
 }
 
-func ParseShellOutput(filename string) (root FSItem) {
-	root = FSItem{Name: "/", Type: Directory}
-	var shell Shell
-	shell = Shell{
-		root: &root,
+// Detach child item from current directory
+func (fi *FSItem) Remove(name string) {
+	child, ok := fi.Children[name]
+	if !ok {
+		return
 	}
-	var command *Command
-	command = &Command{}
-
-	var err error
-	var lineNo uint
-	for line := range ReadLines(filename) {
-		lineNo++
-		if strings.HasPrefix(line, CommandPrompt) {
-			err = command.Parse(line)
-			if err != nil {
-				log.Fatal(err)
-			}
-			err = shell.Execute(command)
-			if err != nil {
-				log.Fatal(err)
-			}
-			continue
-		}
-		if shell.Running.Name == "ls" {
-			shell.ParseLs(line)
-			continue
+	delete(fi.Children, name)
+	child.Parent = nil
+	fi.invalidate()
+}
+
+// Check if other item is located within current one (or is the same item)
+func (fi *FSItem) Contains(other *FSItem) bool {
+	for item := other; item != nil; item = item.Parent {
+		if item == fi {
+			return true
 		}
-		log.Fatalf("shell broke at line %d: %s", lineNo, line)
 	}
-	return root
+	return false
+}
+
+// Absolute path of the item
+func (fi *FSItem) Path() string {
+	if fi.Parent == nil {
+		return fi.Name
+	}
+	parent := fi.Parent.Path()
+	if !strings.HasSuffix(parent, "/") {
+		parent += "/"
+	}
+	return parent + fi.Name
 }
 
 func (fs *FSItem) SpecialSize1() (sum int) {
@@ -195,8 +149,7 @@ func (fs *FSItem) SpecialSize1() (sum int) {
 	return sum
 }
 
-func part1(filename string) (result string) {
-	fs := ParseShellOutput(filename)
+func part1(fs *FSItem) (result string) {
 	return strconv.Itoa(fs.SpecialSize1())
 }
 
@@ -226,13 +179,12 @@ func (s bySize) Less(i, j int) bool {
 	return s[i].Size() < s[j].Size()
 }
 
-func part2(filename string) (result string) {
-	fs := ParseShellOutput(filename)
+func part2(fs *FSItem) (result string) {
 	const target = 70000000 - 30000000
 
 	size := fs.Size()
 	minDelete := size - target
-	found := FindDirs(&fs, minDelete)
+	found := FindDirs(fs, minDelete)
 	sort.Sort(bySize(found))
 	if len(found) == 0 {
 		log.Fatalf("found no directories for part 2")
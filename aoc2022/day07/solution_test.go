@@ -11,7 +11,11 @@ func TestSample(t *testing.T) {
 		"95437",
 		"24933642",
 	}
-	workers := []func(string) string{
+	fs, err := ParseShellOutput(sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	workers := []func(*FSItem) string{
 		part1,
 		part2,
 	}
@@ -19,7 +23,7 @@ func TestSample(t *testing.T) {
 		t.Fatal("mismatch between number of worker functions and expected results")
 	}
 	for i := 0; i < len(results); i++ {
-		got := workers[i](sample)
+		got := workers[i](fs.Copy())
 		expected := results[i]
 		if got != expected {
 			t.Errorf("sample: part %d expected %q, got %q", i+1, expected, got)
@@ -27,14 +31,231 @@ func TestSample(t *testing.T) {
 	}
 }
 
+func replayLines(lines ...string) (*FSItem, error) {
+	input := make(chan string)
+	go func() {
+		for _, line := range lines {
+			input <- line
+		}
+		close(input)
+	}()
+	return Replay(input)
+}
+
+func TestMutations(t *testing.T) {
+	root, err := replayLines(
+		"$ cd /",
+		"$ ls",
+		"dir a",
+		"100 b.txt",
+		"$ mkdir a/c",
+		"$ touch a/c/d 40",
+		"$ touch a/empty",
+		"$ cd a",
+		"$ mv ../b.txt c",
+		"$ cat c/d",
+		"hello",
+		"world",
+		"$ mv c /e",
+		"$ cd /",
+		"$ ls",
+		"dir a",
+		"$ touch e/f 5",
+		"$ rm e/b.txt",
+		"$ mv e/f a/g",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		size int
+		dir  bool
+	}{
+		{"/", 12 + 5, true},
+		{"/a", 5, true},
+		{"/a/g", 5, false},
+		{"/a/empty", 0, false},
+		{"/e", 12, true},
+		{"/e/d", 12, false},
+	}
+	shell := Shell{root: root, CurrentDir: root}
+	for _, test := range tests {
+		item, err := shell.Resolve(test.path)
+		if err != nil {
+			t.Errorf("%s: %v", test.path, err)
+			continue
+		}
+		if item.Size() != test.size || item.IsDir() != test.dir || item.Path() != test.path {
+			t.Errorf("%s: want size %d (dir=%v), got %s with size %d (dir=%v)", test.path, test.size, test.dir, item.Path(), item.Size(), item.IsDir())
+		}
+	}
+	for _, path := range []string{"/b.txt", "/a/c", "/e/b.txt", "/e/f"} {
+		if _, err := shell.Resolve(path); err == nil {
+			t.Errorf("%s: must not exist", path)
+		}
+	}
+}
+
+func TestReplayErrors(t *testing.T) {
+	tests := []struct {
+		lines []string
+		err   string
+	}{
+		{[]string{"$ cd /", "$ pwd"}, "line 2: unknown command: pwd"},
+		{[]string{"$ ls", "dir a", "$ rm a"}, "line 3: rm: is a directory: a"},
+		{[]string{"$ mkdir a", "$ cd a", "$ rm -r /a"}, "line 3: rm: can not remove current directory or its parent: /a"},
+		{[]string{"$ mkdir a", "$ mkdir a/b", "$ mv a a/b"}, "line 3: mv: can not move a into itself"},
+		{[]string{"$ cat missing"}, "line 1: cat: no such file or directory: missing"},
+		{[]string{"$ cd /", "123 orphan"}, "line 2: unexpected output"},
+		{[]string{"$ mkdir x", "$ mkdir x"}, "line 2: mkdir: already exists: x"},
+		{[]string{"$ touch f", "$ cd f"}, "line 2: cd: not a directory: f"},
+	}
+	for _, test := range tests {
+		_, err := replayLines(test.lines...)
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%q: want error %q, got %v", test.lines, test.err, err)
+		}
+	}
+}
+
+func TestSizeCache(t *testing.T) {
+	fs, err := ParseShellOutput(sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shell := Shell{root: fs, CurrentDir: fs}
+	if size := fs.Size(); size != 48381165 {
+		t.Fatalf("total size: want 48381165, got %d", size)
+	}
+	for _, line := range []string{"$ touch a/e/i 1000", "$ mv d/k a/e", "$ rm -r d"} {
+		var cmd Command
+		if err := cmd.Parse(line); err != nil {
+			t.Fatal(err)
+		}
+		if err := shell.Execute(&cmd); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := map[string]int{
+		"/":    14848514 + 8504156 + 29116 + 2557 + 62596 + 1000 + 7214296,
+		"/a":   29116 + 2557 + 62596 + 1000 + 7214296,
+		"/a/e": 1000 + 7214296,
+	}
+	for path, want := range tests {
+		item, err := shell.Resolve(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := item.Size(); got != want {
+			t.Errorf("%s: want size %d, got %d", path, want, got)
+		}
+	}
+}
+
+func TestCopy(t *testing.T) {
+	fs, err := ParseShellOutput(sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := fs.Size()
+	var walk func(item *FSItem)
+	walk = func(item *FSItem) {
+		for name, child := range item.Children {
+			if child.Parent != item {
+				t.Errorf("%s: child %s points to wrong parent", item.Name, name)
+			}
+			walk(child)
+		}
+	}
+	for _, clone := range []*FSItem{fs.Copy().Children["a"], fs.Children["a"].Copy()} {
+		walk(clone)
+		clone.Children["e"].Children["i"].SetFileSize(1)
+		clone.Children["e"].Add(&FSItem{Name: "j", Type: File, fileSize: 1000})
+	}
+	if clone := fs.Children["a"].Copy(); clone.Parent != nil {
+		t.Errorf("copy is attached to %v", clone.Parent)
+	}
+	if got := fs.Size(); got != want {
+		t.Errorf("mutating a copy changed original size: was %d, now %d", want, got)
+	}
+}
+
+func TestReport(t *testing.T) {
+	fs, err := ParseShellOutput(sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		query  string
+		result string
+	}{
+		{"tree /a", "a (94853)\n├── e/ (584)\n│   └── i (584)\n├── f (29116)\n├── g (2557)\n└── h.lst (62596)"},
+		{"du -h", "584\t/a/e\n93K\t/a\n24M\t/d\n47M\t/"},
+		{"du /a", "584\t/a/e\n94853\t/a"},
+		{"find -type d -size -100001 -mindepth 1", "94853\t/a\n584\t/a/e"},
+		{"find / -name d.* -type f", "5626152\t/d/d.ext\n8033020\t/d/d.log"},
+		{"find /d -maxdepth 0", "24933642\t/d"},
+		{"find -size 584", "584\t/a/e\n584\t/a/e/i"},
+		{"find -size +23M", "48381165\t/\n24933642\t/d"},
+		{"find -size -0", ""},
+		{"find -size -0k", ""},
+		{"find -size -1k -type f", "584\t/a/e/i"},
+	}
+	for _, test := range tests {
+		got, err := query(fs.Copy(), test.query)
+		if err != nil {
+			t.Errorf("%s: %v", test.query, err)
+			continue
+		}
+		if got != test.result {
+			t.Errorf("%s: want\n%s\ngot\n%s", test.query, test.result, got)
+		}
+	}
+	for _, invalid := range []string{"", "ls", "tree /x", "find -type x", "find -size", "find -depth 1", "du /b.txt"} {
+		if _, err := query(fs.Copy(), invalid); err == nil {
+			t.Errorf("%q: want error, got nil", invalid)
+		}
+	}
+}
+
+func TestHumanSize(t *testing.T) {
+	tests := map[int]string{
+		0:             "0",
+		1023:          "1023",
+		1024:          "1.0K",
+		1025:          "1.1K",
+		10239:         "10K",
+		94853:         "93K",
+		1 << 20:       "1.0M",
+		48381165:      "47M",
+		5 * (1 << 30): "5.0G",
+	}
+	for size, want := range tests {
+		if got := HumanSize(size); got != want {
+			t.Errorf("HumanSize(%d): want %q, got %q", size, want, got)
+		}
+	}
+}
+
 func BenchmarkPart1(b *testing.B) {
+	fs, err := ParseShellOutput(sample)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		part1(sample)
+		part1(fs.Copy())
 	}
 }
 
 func BenchmarkPart2(b *testing.B) {
+	fs, err := ParseShellOutput(sample)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		part2(sample)
+		part2(fs.Copy())
 	}
 }
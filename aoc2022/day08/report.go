@@ -0,0 +1,199 @@
+package main
+
+import (
+	"container/heap"
+	"encoding/csv"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"aoc2022/puzzle"
+)
+
+// Export survey results for every tree, row by row
+func (s *Survey) WriteCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	err := out.Write([]string{"X", "Y", "height", "visible", "score", "viewN", "viewS", "viewE", "viewW"})
+	if err != nil {
+		return err
+	}
+	record := make([]string, 9)
+	for y := 0; y < s.trees.Height(); y++ {
+		for x := 0; x < s.trees.Width(); x++ {
+			location := Location{x, y}
+			record[0] = strconv.Itoa(x)
+			record[1] = strconv.Itoa(y)
+			record[2] = strconv.Itoa(int(s.trees.Get(location)))
+			record[3] = strconv.FormatBool(s.Visible(location))
+			record[4] = strconv.Itoa(s.ScenicScore(location))
+			for i, direction := range directions {
+				record[5+i] = strconv.Itoa(s.View(location, direction))
+			}
+			err = out.Write(record)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// Render scenic scores as an image, each tree takes scale x scale pixels
+//
+// Colors go from black through red and yellow to white. Square root of the
+// score is used because a few trees usually score much higher than the rest.
+func (s *Survey) Heatmap(scale int) image.Image {
+	if scale < 1 {
+		scale = 1
+	}
+	var best int
+	for y := 0; y < s.trees.Height(); y++ {
+		for x := 0; x < s.trees.Width(); x++ {
+			if score := s.ScenicScore(Location{x, y}); score > best {
+				best = score
+			}
+		}
+	}
+	img := image.NewRGBA(image.Rect(0, 0, s.trees.Width()*scale, s.trees.Height()*scale))
+	for y := 0; y < s.trees.Height(); y++ {
+		for x := 0; x < s.trees.Width(); x++ {
+			var heat float64
+			if best > 0 {
+				heat = math.Sqrt(float64(s.ScenicScore(Location{x, y})) / float64(best))
+			}
+			c := heatColor(heat)
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetRGBA(x*scale+dx, y*scale+dy, c)
+				}
+			}
+		}
+	}
+	return img
+}
+
+// Map value from [0, 1] to black-red-yellow-white gradient
+func heatColor(heat float64) color.RGBA {
+	channel := func(offset float64) uint8 {
+		v := (heat*3 - offset) * 255
+		return uint8(math.Max(0, math.Min(255, v)))
+	}
+	return color.RGBA{R: channel(0), G: channel(1), B: channel(2), A: 255}
+}
+
+type Viewpoint struct {
+	Location Location
+	Height   TreeHeight
+	Score    int
+}
+
+func (v Viewpoint) String() string {
+	return fmt.Sprintf("X=%d, Y=%d, height=%d, score=%d", v.Location.X, v.Location.Y, v.Height, v.Score)
+}
+
+// Trees with the highest scenic scores, ties are resolved in reading order
+//
+// Only n best trees are kept in memory at any time.
+func (s *Survey) Top(n int) []Viewpoint {
+	if n <= 0 {
+		return []Viewpoint{}
+	}
+	top := make(viewpointHeap, 0, n+1)
+	for y := 0; y < s.trees.Height(); y++ {
+		for x := 0; x < s.trees.Width(); x++ {
+			location := Location{x, y}
+			point := Viewpoint{location, s.trees.Get(location), s.ScenicScore(location)}
+			if len(top) < n {
+				heap.Push(&top, point)
+			} else if point.Before(top[0]) {
+				top[0] = point
+				heap.Fix(&top, 0)
+			}
+		}
+	}
+	points := []Viewpoint(top)
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Before(points[j])
+	})
+	return points
+}
+
+// Higher scores go first, ties are resolved in reading order
+func (v Viewpoint) Before(other Viewpoint) bool {
+	if v.Score != other.Score {
+		return v.Score > other.Score
+	}
+	if v.Location.Y != other.Location.Y {
+		return v.Location.Y < other.Location.Y
+	}
+	return v.Location.X < other.Location.X
+}
+
+// Min-heap that keeps the worst of selected viewpoints on top
+type viewpointHeap []Viewpoint
+
+func (h viewpointHeap) Len() int           { return len(h) }
+func (h viewpointHeap) Less(i, j int) bool { return h[j].Before(h[i]) }
+func (h viewpointHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *viewpointHeap) Push(x any)        { *h = append(*h, x.(Viewpoint)) }
+func (h *viewpointHeap) Pop() any {
+	old := *h
+	last := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return last
+}
+
+// Answer a question about the forest:
+//
+//	top N               trees with the highest scenic scores
+//	csv FILE            save per tree report in CSV format
+//	png FILE [SCALE]    save heatmap of scenic scores
+func query(trees *Map, question string) (string, error) {
+	words := strings.Fields(question)
+	survey := trees.Survey()
+	switch {
+	case len(words) == 2 && words[0] == "top":
+		n, err := strconv.Atoi(words[1])
+		if err != nil || n < 0 {
+			return "", fmt.Errorf("invalid number of viewpoints: %s", words[1])
+		}
+		top := survey.Top(n)
+		lines := make([]string, len(top))
+		for i, point := range top {
+			lines[i] = point.String()
+		}
+		return strings.Join(lines, "\n"), nil
+	case len(words) == 2 && words[0] == "csv":
+		err := puzzle.WriteFile(words[1], survey.WriteCSV)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("saved %d trees to %s", len(survey.views), words[1]), nil
+	case (len(words) == 2 || len(words) == 3) && words[0] == "png":
+		scale := 1
+		if len(words) == 3 {
+			var err error
+			scale, err = strconv.Atoi(words[2])
+			if err != nil || scale < 1 {
+				return "", fmt.Errorf("invalid scale: %s", words[2])
+			}
+		}
+		img := survey.Heatmap(scale)
+		err := puzzle.WriteFile(words[1], func(w io.Writer) error {
+			return png.Encode(w, img)
+		})
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("saved %dx%d heatmap to %s", img.Bounds().Dx(), img.Bounds().Dy(), words[1]), nil
+	default:
+		return "", fmt.Errorf("unsupported query: %q (expected: top N, csv FILE, png FILE [SCALE])", question)
+	}
+}
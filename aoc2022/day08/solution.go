@@ -1,213 +1,181 @@
 package main
 
 import (
-	"log"
+	"fmt"
 	"strconv"
+
+	"aoc2022/puzzle"
 )
 
 type TreeHeight uint8
 
 type Location struct {
-	X uint
-	Y uint
+	X int
+	Y int
 }
 
+// Dense grid of tree heights, row by row
 type Map struct {
-	maxX uint
-	maxY uint
-	grid map[Location]TreeHeight
+	width  int
+	height int
+	grid   []TreeHeight
 }
 
-func (m *Map) Get(location Location) TreeHeight {
-	return m.grid[location]
+func (m *Map) Width() int {
+	return m.width
 }
 
-func (m *Map) Exists(location Location) bool {
-	_, exists := m.grid[location]
-	return exists
+func (m *Map) Height() int {
+	return m.height
 }
 
-func (m *Map) Set(location Location, value TreeHeight) {
-	if location.X > m.maxX {
-		m.maxX = location.X
-	}
-	if location.Y > m.maxY {
-		m.maxY = location.Y
-	}
-	if m.grid == nil {
-		m.grid = make(map[Location]TreeHeight)
-	}
-	m.grid[location] = value
+func (m *Map) index(location Location) int {
+	return location.Y*m.width + location.X
 }
 
-func (m *Map) Visible(location Location) bool {
-	var x, y uint
-	x, y = location.X, location.Y
+func (m *Map) Get(location Location) TreeHeight {
+	return m.grid[m.index(location)]
+}
 
-	if x == 0 || x == m.maxX || y == 0 || y == m.maxY {
-		return true // at the edge of the map
-	}
+func (m *Map) Exists(location Location) bool {
+	return location.X >= 0 && location.X < m.width && location.Y >= 0 && location.Y < m.height
+}
 
-	var height TreeHeight
-	height = m.Get(location)
+func (m *Map) Set(location Location, value TreeHeight) {
+	m.grid[m.index(location)] = value
+}
 
-	var i uint
-	var cursor *Location
-	cursor = &Location{}
-	for i = 0; i <= x; i++ {
-		if i == x {
-			return true // visible from the left
-		}
-		cursor.X = i
-		cursor.Y = y
-		if m.Get(*cursor) >= height {
-			break
-		}
-	}
-	for i = m.maxX; i >= x; i-- {
-		if i == x {
-			return true // visible from the right
-		}
-		cursor.X = i
-		cursor.Y = y
-		if m.Get(*cursor) >= height {
-			break
-		}
-	}
-	for i = 0; i <= y; i++ {
-		if i == y {
-			return true // visible from the top
-		}
-		cursor.X = x
-		cursor.Y = i
-		if m.Get(*cursor) >= height {
-			break
-		}
+// Append a row of trees, all rows must have the same length
+func (m *Map) AddRow(row []TreeHeight) error {
+	if m.height == 0 {
+		m.width = len(row)
 	}
-	for i = m.maxY; i >= y; i-- {
-		if i == y {
-			return true // visible from the bottom
-		}
-		cursor.X = x
-		cursor.Y = i
-		if m.Get(*cursor) >= height {
-			break
-		}
+	if len(row) != m.width {
+		return fmt.Errorf("row %d: expected %d trees, got %d", m.height+1, m.width, len(row))
 	}
-	return false
+	m.grid = append(m.grid, row...)
+	m.height++
+	return nil
 }
 
-func (m *Map) ScenicScore(location Location) uint {
-	var score uint
-	score = 1
+func (m *Map) Copy() *Map {
+	clone := *m
+	clone.grid = append([]TreeHeight(nil), m.grid...)
+	return &clone
+}
 
-	var cursor Location
-	cursor = location
+type Direction uint8
 
-	var height TreeHeight
-	height = m.Get(location)
+const (
+	North Direction = iota
+	South
+	East
+	West
+)
 
-	var i, seen uint
-	seen = 0
-	for i = location.X + 1; i <= m.maxX; i++ {
-		cursor.X = i
-		cursor.Y = location.Y
-		if !m.Exists(cursor) {
-			break
-		}
-		seen++
-		if m.Get(cursor) >= height {
-			break
-		}
+var directions = [...]Direction{North, South, East, West}
+
+func (d Direction) Step() (dx, dy int) {
+	switch d {
+	case North:
+		return 0, -1
+	case South:
+		return 0, 1
+	case East:
+		return 1, 0
+	case West:
+		return -1, 0
 	}
-	score *= seen
+	panic(fmt.Sprintf("invalid direction: %d", d))
+}
 
-	seen = 0
-	for i = location.X - 1; i >= 0; i-- {
-		cursor.X = i
-		cursor.Y = location.Y
+// Number of trees seen from location when looking in given direction,
+// and whether the tree itself is visible from outside of the grid in that
+// direction
+//
+// This is a straightforward scan, use Survey to process the whole grid.
+func (m *Map) Look(location Location, direction Direction) (distance int, visible bool) {
+	height := m.Get(location)
+	dx, dy := direction.Step()
+	cursor := location
+	for {
+		cursor.X += dx
+		cursor.Y += dy
 		if !m.Exists(cursor) {
-			break
+			return distance, true
 		}
-		seen++
+		distance++
 		if m.Get(cursor) >= height {
-			break
+			return distance, false
 		}
 	}
-	score *= seen
+}
 
-	seen = 0
-	for i = location.Y + 1; i <= m.maxY; i++ {
-		cursor.X = location.X
-		cursor.Y = i
-		if !m.Exists(cursor) {
-			break
-		}
-		seen++
-		if m.Get(cursor) >= height {
-			break
+func (m *Map) Visible(location Location) bool {
+	for _, direction := range directions {
+		if _, visible := m.Look(location, direction); visible {
+			return true
 		}
 	}
-	score *= seen
+	return false
+}
 
-	seen = 0
-	for i = location.Y - 1; i >= 0; i-- {
-		cursor.X = location.X
-		cursor.Y = i
-		if !m.Exists(cursor) {
-			break
-		}
-		seen++
-		if m.Get(cursor) >= height {
-			break
-		}
+func (m *Map) ScenicScore(location Location) int {
+	score := 1
+	for _, direction := range directions {
+		distance, _ := m.Look(location, direction)
+		score *= distance
 	}
-	score *= seen
-
 	return score
 }
 
-func ReadMap(filename string) *Map {
-	var cursor *Location
-	cursor = &Location{0, 0}
-	var trees *Map
-	trees = &Map{}
-	var height int
-	var err error
-	for line := range ReadLines(filename) {
-		cursor.X = 0
+func ReadMap(filename string) (*Map, error) {
+	trees := &Map{}
+	lines, err := puzzle.ReadLines(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+	for line := range lines.C {
+		row := make([]TreeHeight, 0, len(line))
 		for _, char := range line {
-			height, err = strconv.Atoi(string(char))
-			if err != nil {
-				log.Fatalf("could not parse tree height: %s", string(char))
+			if char < '0' || char > '9' {
+				return nil, fmt.Errorf("could not parse tree height: %s", string(char))
 			}
-			trees.Set(*cursor, TreeHeight(height))
-			cursor.X++
+			row = append(row, TreeHeight(char-'0'))
 		}
-		cursor.Y++
+		err := trees.AddRow(row)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = lines.Close()
+	if err != nil {
+		return nil, err
 	}
-	return trees
+	return trees, nil
 }
 
-func part1(filename string) string {
-	trees := ReadMap(filename)
+func part1(trees *Map) string {
+	survey := trees.Survey()
 	var result int
-	for location := range trees.grid {
-		if trees.Visible(location) {
+	for _, mask := range survey.visibleFrom {
+		if mask != 0 {
 			result++
 		}
 	}
 	return strconv.Itoa(result)
 }
 
-func part2(filename string) string {
-	trees := ReadMap(filename)
-	var max, current uint
-	for location := range trees.grid {
-		current = trees.ScenicScore(location)
-		if current > max {
-			max = current
+func part2(trees *Map) string {
+	survey := trees.Survey()
+	var max int
+	for y := 0; y < trees.Height(); y++ {
+		for x := 0; x < trees.Width(); x++ {
+			if score := survey.ScenicScore(Location{x, y}); score > max {
+				max = score
+			}
 		}
 	}
-	return strconv.Itoa(int(max))
+	return strconv.Itoa(max)
 }
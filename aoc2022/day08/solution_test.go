@@ -1,6 +1,10 @@
 package main
 
 import (
+	"bytes"
+	"image/color"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -11,7 +15,11 @@ func TestSample(t *testing.T) {
 		"21",
 		"8",
 	}
-	workers := []func(string) string{
+	trees, err := ReadMap(sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	workers := []func(*Map) string{
 		part1,
 		part2,
 	}
@@ -19,7 +27,7 @@ func TestSample(t *testing.T) {
 		t.Fatal("mismatch between number of worker functions and expected results")
 	}
 	for i := 0; i < len(results); i++ {
-		got := workers[i](sample)
+		got := workers[i](trees.Copy())
 		expected := results[i]
 		if got != expected {
 			t.Errorf("sample: part %d expected %q, got %q", i+1, expected, got)
@@ -27,14 +35,65 @@ func TestSample(t *testing.T) {
 	}
 }
 
+func TestReport(t *testing.T) {
+	trees, err := ReadMap(sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	survey := trees.Survey()
+
+	top := survey.Top(2)
+	want := []Viewpoint{{Location{2, 3}, 5, 8}, {Location{1, 2}, 5, 6}}
+	if !reflect.DeepEqual(top, want) {
+		t.Errorf("top viewpoints: want %v, got %v", want, top)
+	}
+
+	var csv bytes.Buffer
+	err = survey.WriteCSV(&csv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(csv.String()), "\n")
+	if len(lines) != 26 {
+		t.Fatalf("csv: want 26 lines, got %d", len(lines))
+	}
+	if lines[0] != "X,Y,height,visible,score,viewN,viewS,viewE,viewW" {
+		t.Errorf("csv: unexpected header: %s", lines[0])
+	}
+	if row := lines[1+3*5+2]; row != "2,3,5,true,8,2,1,2,2" {
+		t.Errorf("csv: unexpected row for best viewpoint: %s", row)
+	}
+
+	img := survey.Heatmap(3)
+	if size := img.Bounds().Size(); size.X != 15 || size.Y != 15 {
+		t.Errorf("heatmap: want 15x15 pixels, got %v", size)
+	}
+	if c := img.At(2*3+1, 3*3+1); c != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("heatmap: best viewpoint must be white, got %v", c)
+	}
+	if c := img.At(0, 0); c != (color.RGBA{0, 0, 0, 255}) {
+		t.Errorf("heatmap: edge trees must be black, got %v", c)
+	}
+}
+
 func BenchmarkPart1(b *testing.B) {
+	trees, err := ReadMap(sample)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		part1(sample)
+		part1(trees.Copy())
 	}
 }
 
 func BenchmarkPart2(b *testing.B) {
+	trees, err := ReadMap(sample)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		part2(sample)
+		part2(trees.Copy())
 	}
 }
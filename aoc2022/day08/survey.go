@@ -0,0 +1,96 @@
+package main
+
+// Viewing distance and visibility of every tree in the grid
+type Survey struct {
+	trees       *Map
+	views       [][4]int // indexed by Direction
+	visibleFrom []uint8  // bit mask of directions
+}
+
+// Examine all trees in four sweeps, one per direction
+//
+// Each sweep walks the lines of the grid starting from the edge the trees are
+// looking at and keeps a monotonic stack of trees already passed, which are
+// the ones in the line of view: trees lower than the current one can never
+// block the view of the following trees, so they are popped. Whatever
+// remains on top of the stack is the tree that blocks the view. Every tree
+// is pushed and popped at most once per sweep, so the whole survey takes
+// linear time.
+func (m *Map) Survey() *Survey {
+	survey := &Survey{
+		trees:       m,
+		views:       make([][4]int, len(m.grid)),
+		visibleFrom: make([]uint8, len(m.grid)),
+	}
+	stack := make([]int, 0, larger(m.width, m.height))
+	for _, direction := range directions {
+		dx, dy := direction.Step()
+
+		// Lines are walked from the edge in the direction of view, so that
+		// trees in front of the current one are already on the stack
+		lines, length := m.height, m.width
+		if dx == 0 {
+			lines, length = m.width, m.height
+		}
+		for line := 0; line < lines; line++ {
+			stack = stack[:0]
+			for step := 0; step < length; step++ {
+				position := step
+				if dx > 0 || dy > 0 {
+					position = length - 1 - step
+				}
+				location := Location{X: position, Y: line}
+				if dx == 0 {
+					location = Location{X: line, Y: position}
+				}
+				index := m.index(location)
+				height := m.grid[index]
+				for len(stack) > 0 && m.grid[stack[len(stack)-1]] < height {
+					stack = stack[:len(stack)-1]
+				}
+				if len(stack) == 0 {
+					survey.views[index][direction] = step // all the way to the edge
+					survey.visibleFrom[index] |= 1 << direction
+				} else {
+					blocker := stack[len(stack)-1]
+					survey.views[index][direction] = (index - blocker) / m.stride(direction)
+				}
+				stack = append(stack, index)
+			}
+		}
+	}
+	return survey
+}
+
+// Difference between grid indexes of two neighboring trees on the line of view
+func (m *Map) stride(direction Direction) int {
+	dx, dy := direction.Step()
+	return -(dx + dy*m.width)
+}
+
+func (s *Survey) Visible(location Location) bool {
+	return s.visibleFrom[s.trees.index(location)] != 0
+}
+
+func (s *Survey) VisibleFrom(location Location, direction Direction) bool {
+	return s.visibleFrom[s.trees.index(location)]&(1<<direction) != 0
+}
+
+func (s *Survey) View(location Location, direction Direction) int {
+	return s.views[s.trees.index(location)][direction]
+}
+
+func (s *Survey) ScenicScore(location Location) int {
+	score := 1
+	for _, distance := range s.views[s.trees.index(location)] {
+		score *= distance
+	}
+	return score
+}
+
+func larger(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
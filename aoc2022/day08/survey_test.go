@@ -0,0 +1,131 @@
+package main
+
+import (
+	"testing"
+
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+
+	"aoc2022/puzzle/puzzletest"
+)
+
+// Forest with uniformly distributed tree heights
+func randomForest(tb testing.TB, rng *rand.Rand, width, height int) *Map {
+	tb.Helper()
+	var b strings.Builder
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			b.WriteByte(byte('0' + rng.Intn(10)))
+		}
+		b.WriteByte('\n')
+	}
+	trees, err := ReadMap(puzzletest.WriteInput(tb, b.String()))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return trees
+}
+
+// Survey must agree with straightforward scans for every tree
+func checkSurvey(t *testing.T, trees *Map, width, height int) {
+	t.Helper()
+	survey := trees.Survey()
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			location := Location{x, y}
+			for _, direction := range directions {
+				distance, visible := trees.Look(location, direction)
+				if survey.View(location, direction) != distance || survey.VisibleFrom(location, direction) != visible {
+					t.Fatalf(
+						"%v looking %d: want distance %d (visible=%v), got %d (visible=%v)",
+						location,
+						direction,
+						distance,
+						visible,
+						survey.View(location, direction),
+						survey.VisibleFrom(location, direction),
+					)
+				}
+			}
+			if survey.Visible(location) != trees.Visible(location) || survey.ScenicScore(location) != trees.ScenicScore(location) {
+				t.Fatalf("%v: survey does not match straightforward scan", location)
+			}
+		}
+	}
+}
+
+func TestRandomForest(t *testing.T) {
+	puzzletest.Seeds(t, 20, func(t *testing.T, seed int64, rng *rand.Rand) {
+		width, height := 1+rng.Intn(60), 1+rng.Intn(60)
+		checkSurvey(t, randomForest(t, rng, width, height), width, height)
+	})
+}
+
+// Large forests, including one of low trees with rare tall ones, where
+// lines of view are long
+func TestLargeForest(t *testing.T) {
+	if testing.Short() {
+		t.Skip("quadratic reference is slow on large forests")
+	}
+	rng := rand.New(rand.NewSource(8))
+	checkSurvey(t, randomForest(t, rng, 1000, 700), 1000, 700)
+
+	width, height := 800, 900
+	var b strings.Builder
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			tree := byte('0' + rng.Intn(3))
+			if rng.Intn(200) == 0 {
+				tree = byte('3' + rng.Intn(7))
+			}
+			b.WriteByte(tree)
+		}
+		b.WriteByte('\n')
+	}
+	trees, err := ReadMap(puzzletest.WriteInput(t, b.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSurvey(t, trees, width, height)
+}
+
+// Bounded heap must select the same trees as sorting all of them
+func TestTop(t *testing.T) {
+	puzzletest.Seeds(t, 10, func(t *testing.T, seed int64, rng *rand.Rand) {
+		width, height := 1+rng.Intn(20), 1+rng.Intn(20)
+		survey := randomForest(t, rng, width, height).Survey()
+		var all []Viewpoint
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				location := Location{x, y}
+				all = append(all, Viewpoint{location, survey.trees.Get(location), survey.ScenicScore(location)})
+			}
+		}
+		sort.SliceStable(all, func(i, j int) bool {
+			return all[i].Score > all[j].Score
+		})
+		for _, n := range []int{0, 1, 5, width * height, width*height + 1} {
+			want := all
+			if n < len(want) {
+				want = want[:n]
+			}
+			if got := survey.Top(n); !reflect.DeepEqual(got, want) {
+				t.Errorf("top %d: want %v, got %v", n, want, got)
+			}
+		}
+	})
+}
+
+func BenchmarkRandomForest(b *testing.B) {
+	for _, size := range []int{100, 500, 1000} {
+		trees := randomForest(b, rand.New(rand.NewSource(1)), size, size)
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				part2(trees.Copy())
+			}
+		})
+	}
+}
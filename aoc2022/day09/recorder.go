@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Positions of all knots after every step of the head
+//
+// Frame 0 is the initial state of the rope.
+type Recorder struct {
+	Frames [][]Position
+}
+
+func NewRecorder(knots []Position) *Recorder {
+	r := &Recorder{}
+	r.Record(knots)
+	return r
+}
+
+func (r *Recorder) Record(knots []Position) {
+	r.Frames = append(r.Frames, append([]Position(nil), knots...))
+}
+
+// Half-open range of frames, negative To means up to the last frame
+type FrameRange struct {
+	From int
+	To   int
+}
+
+// Parse range of frames: "FROM:TO", "FROM:", ":TO" or a single frame number
+func ParseFrameRange(text string) (FrameRange, error) {
+	from, to, isRange := strings.Cut(text, ":")
+	frames := FrameRange{To: -1}
+	var err error
+	if from != "" {
+		frames.From, err = strconv.Atoi(from)
+		if err != nil || frames.From < 0 {
+			return frames, fmt.Errorf("invalid frame number: %s", from)
+		}
+	}
+	if !isRange {
+		frames.To = frames.From + 1
+		return frames, nil
+	}
+	if to != "" {
+		frames.To, err = strconv.Atoi(to)
+		if err != nil || frames.To < 0 {
+			return frames, fmt.Errorf("invalid frame number: %s", to)
+		}
+	}
+	return frames, nil
+}
+
+// Clamp range to recorded frames
+func (r *Recorder) bounds(frames FrameRange) (from, to int, err error) {
+	from, to = frames.From, frames.To
+	if to < 0 || to > len(r.Frames) {
+		to = len(r.Frames)
+	}
+	if from >= to {
+		return 0, 0, fmt.Errorf("no frames in range %d:%d (recorded %d frames)", frames.From, frames.To, len(r.Frames))
+	}
+	return from, to, nil
+}
+
+// Symbols for the number of times the tail has arrived at a position
+const heatScale = ":-=+*#%@"
+
+// Print every frame in range, all frames share the same viewport
+//
+// Viewport fits all knots within the range and the starting position. With
+// heat overlay the positions visited by the tail are shown too, brighter
+// symbols mean the tail has come there more often.
+func (r *Recorder) Render(w io.Writer, frames FrameRange, heat bool) error {
+	from, to, err := r.bounds(frames)
+	if err != nil {
+		return err
+	}
+	view := r.viewport(from, to, heat)
+	arrivals := make(map[Position]int)
+	for index := 0; index < to; index++ {
+		tail := r.Frames[index][len(r.Frames[index])-1]
+		if index == 0 || tail != r.Frames[index-1][len(r.Frames[index-1])-1] {
+			arrivals[tail]++
+		}
+		if index < from {
+			continue
+		}
+		overlay := arrivals
+		if !heat {
+			overlay = nil
+		}
+		_, err = fmt.Fprintf(w, "::: Frame %d, head at %v :::\n%s\n\n", index, r.Frames[index][0], view.render(r.Frames[index], overlay))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Render single frame without heat overlay, viewport fits that frame only
+func (r *Recorder) Frame(index int) string {
+	return r.viewport(index, index+1, false).render(r.Frames[index], nil)
+}
+
+// Rectangle of the grid to be rendered
+type viewport struct {
+	min, max Position
+}
+
+func (v *viewport) fit(p Position) {
+	if p.X < v.min.X {
+		v.min.X = p.X
+	}
+	if p.Y < v.min.Y {
+		v.min.Y = p.Y
+	}
+	if p.X > v.max.X {
+		v.max.X = p.X
+	}
+	if p.Y > v.max.Y {
+		v.max.Y = p.Y
+	}
+}
+
+func (r *Recorder) viewport(from, to int, heat bool) viewport {
+	var view viewport // includes starting position
+	start := from
+	if heat {
+		start = 0 // tail trace starts at the very beginning
+	}
+	for index := start; index < to; index++ {
+		frame := r.Frames[index]
+		if index < from {
+			view.fit(frame[len(frame)-1])
+			continue
+		}
+		for _, knot := range frame {
+			view.fit(knot)
+		}
+	}
+	return view
+}
+
+// Draw the grid with Y axis pointing up
+func (v viewport) render(knots []Position, arrivals map[Position]int) string {
+	width := v.max.X - v.min.X + 1
+	height := v.max.Y - v.min.Y + 1
+	grid := make([][]byte, height)
+	for row := range grid {
+		grid[row] = []byte(strings.Repeat(".", width))
+	}
+	set := func(p Position, symbol byte) {
+		grid[v.max.Y-p.Y][p.X-v.min.X] = symbol
+	}
+	for p, count := range arrivals {
+		if count > len(heatScale) {
+			count = len(heatScale)
+		}
+		set(p, heatScale[count-1])
+	}
+	set(Position{}, 's')
+	for i := len(knots) - 1; i >= 0; i-- { // knots closer to head are drawn on top
+		set(knots[i], knotSymbol(i, len(knots)))
+	}
+	lines := make([]string, height)
+	for row := range grid {
+		lines[row] = string(grid[row])
+	}
+	return strings.Join(lines, "\n")
+}
+
+func knotSymbol(index, knots int) byte {
+	switch {
+	case index == 0:
+		return 'H'
+	case index == knots-1 && knots == 2:
+		return 'T'
+	case index < 10:
+		return '0' + byte(index)
+	default:
+		return 'A' + byte((index-10)%26)
+	}
+}
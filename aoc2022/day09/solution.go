@@ -1,10 +1,14 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
+	"os"
 	"strconv"
 	"strings"
+
+	"aoc2022/puzzle"
 )
 
 type Position struct {
@@ -22,7 +26,7 @@ var (
 )
 
 func (this *Position) Touches(other Position) bool {
-	return abs(this.X-other.X) <= 1 && abs(this.Y-other.Y) <= 1
+	return Chebyshev.Distance(*this, other) <= 1
 }
 
 func (point *Position) Move(delta Direction) {
@@ -50,14 +54,41 @@ func sign(num int) int {
 	}
 }
 
+// Knots of the rope, head first
 type Rope struct {
-	Head  Position
-	Next  *Rope
-	Trace map[Position]bool
+	Knots  []Position
+	Traces []map[Position]bool // positions visited by each knot
+	Rule   FollowRule
+
+	Recorder *Recorder // optional
 }
 
-func (r *Rope) Last() bool {
-	return r.Next == nil
+func NewRope(knots int, rule FollowRule) *Rope {
+	if knots < 2 {
+		panic("the rope must contain at least 2 knots")
+	}
+	rope := &Rope{
+		Knots:  make([]Position, knots),
+		Traces: make([]map[Position]bool, knots),
+		Rule:   rule,
+	}
+	for i := range rope.Traces {
+		rope.Traces[i] = map[Position]bool{rope.Knots[i]: true} // log initial position
+	}
+	return rope
+}
+
+func (r *Rope) Head() Position {
+	return r.Knots[0]
+}
+
+func (r *Rope) Tail() Position {
+	return r.Knots[len(r.Knots)-1]
+}
+
+// Positions visited by the last knot
+func (r *Rope) Trace() map[Position]bool {
+	return r.Traces[len(r.Traces)-1]
 }
 
 func (r *Rope) MoveN(delta Direction, repeat int) {
@@ -69,42 +100,106 @@ func (r *Rope) MoveN(delta Direction, repeat int) {
 	}
 }
 
+// Move head by one step and let other knots follow
 func (r *Rope) Move(delta Direction) {
-	r.Head.Move(delta)
-	if r.Last() {
-		r.Trace[r.Head] = true // log new position after move
+	r.move(delta)
+	if r.Recorder != nil {
+		r.Recorder.Record(r.Knots)
 	}
-	if r.Last() || r.Next.Head.Touches(r.Head) {
-		return
+}
+
+func (r *Rope) move(delta Direction) {
+	r.Knots[0].Move(delta)
+	r.Traces[0][r.Knots[0]] = true
+	for i := 1; i < len(r.Knots); i++ {
+		leader, follower := &r.Knots[i-1], &r.Knots[i]
+		moved := false
+		for step := 0; ; step++ {
+			catchup, ok := r.Rule.Follow(*leader, *follower)
+			if !ok {
+				break
+			}
+			if step > abs(leader.X-follower.X)+abs(leader.Y-follower.Y) {
+				panic(fmt.Sprintf("knot %d does not catch up with %v from %v using %v", i, *leader, *follower, r.Rule))
+			}
+			follower.Move(catchup)
+			r.Traces[i][*follower] = true
+			moved = true
+		}
+		if !moved {
+			return // knots further down the rope stay in place too
+		}
 	}
+}
+
+// Defines how a knot catches up with the one in front of it
+type FollowRule interface {
+	// Next step for follower, false if it does not need to move
+	Follow(leader, follower Position) (Direction, bool)
+}
 
-	var catchup Direction
-	catchup = Direction{
-		X: sign(r.Head.X - r.Next.Head.X),
-		Y: sign(r.Head.Y - r.Next.Head.Y),
+type Metric uint8
+
+const (
+	Chebyshev Metric = iota // diagonal neighbors are adjacent, knots move diagonally
+	Manhattan               // only orthogonal neighbors are adjacent, knots move orthogonally
+)
+
+func (m Metric) Distance(a, b Position) int {
+	dx, dy := abs(a.X-b.X), abs(a.Y-b.Y)
+	if m == Manhattan {
+		return dx + dy
 	}
-	r.Next.Move(catchup)
-	if !r.Next.Head.Touches(r.Head) {
-		panic("tail did not reattach to head after move!")
+	if dx > dy {
+		return dx
 	}
+	return dy
 }
 
-func NewRope(knots int) (head, tail *Rope) {
-	if knots < 2 {
-		panic("the rope must contain at least 2 knots")
+func (m Metric) String() string {
+	if m == Manhattan {
+		return "manhattan"
 	}
+	return "chebyshev"
+}
 
-	tail = &Rope{}
-	tail.Trace = make(map[Position]bool)
-	tail.Trace[tail.Head] = true // log initial position
+func ParseMetric(name string) (Metric, error) {
+	switch strings.ToLower(name) {
+	case "chebyshev":
+		return Chebyshev, nil
+	case "manhattan":
+		return Manhattan, nil
+	default:
+		return 0, fmt.Errorf("unknown metric: %s", name)
+	}
+}
+
+// Follower stays in place while it is within given distance from leader,
+// otherwise it steps towards the leader
+//
+// Puzzle rules are Slack{1, Chebyshev}. With Manhattan metric the follower
+// moves along the axis where the gap is bigger, preferring X on ties.
+type Slack struct {
+	Distance int
+	Metric   Metric
+}
 
-	var next *Rope
-	next = tail
-	for i := 0; i < knots-1; i++ {
-		head = &Rope{Next: next}
-		next = head
+func (s Slack) Follow(leader, follower Position) (Direction, bool) {
+	if s.Metric.Distance(leader, follower) <= s.Distance {
+		return Direction{}, false
 	}
-	return head, tail
+	dx, dy := leader.X-follower.X, leader.Y-follower.Y
+	if s.Metric == Manhattan {
+		if abs(dx) >= abs(dy) {
+			return Direction{sign(dx), 0}, true
+		}
+		return Direction{0, sign(dy)}, true
+	}
+	return Direction{sign(dx), sign(dy)}, true
+}
+
+func (s Slack) String() string {
+	return fmt.Sprintf("slack %d (%v)", s.Distance, s.Metric)
 }
 
 type Motion struct {
@@ -112,85 +207,99 @@ type Motion struct {
 	repeat    int
 }
 
-func ReadSteps(filename string, motions chan<- Motion) {
-	defer close(motions)
-	var step Direction
-	var line, command, arg string
-	var repeat int
-	directions := map[string]Direction{
-		"R": Right,
-		"L": Left,
-		"U": Up,
-		"D": Down,
-	}
-	var ok bool
-	var err error
-	for line = range ReadLines(filename) {
-		command, arg, ok = strings.Cut(line, " ")
+var stepDirections = map[string]Direction{
+	"R":  Right,
+	"L":  Left,
+	"U":  Up,
+	"D":  Down,
+	"UR": {1, 1},
+	"UL": {-1, 1},
+	"DR": {1, -1},
+	"DL": {-1, -1},
+}
+
+type Motions []Motion
+
+func ReadMotions(filename string) (Motions, error) {
+	var motions Motions
+	lines, err := puzzle.ReadLines(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+	for line := range lines.C {
+		command, arg, ok := strings.Cut(line, " ")
 		if !ok {
-			log.Fatalf("invalid command: %s", line)
+			return nil, fmt.Errorf("invalid command: %s", line)
 		}
-		step, ok = directions[command]
+		step, ok := stepDirections[command]
 		if !ok {
-			log.Fatalf("unsupported command (%s): %s", command, line)
+			return nil, fmt.Errorf("unsupported command (%s): %s", command, line)
 		}
-		repeat, err = strconv.Atoi(arg)
+		repeat, err := strconv.Atoi(arg)
 		if err != nil {
-			log.Fatalf("cannot parse number of steps (%s): %s", arg, line)
+			return nil, fmt.Errorf("cannot parse number of steps (%s): %s", arg, line)
 		}
-		motions <- Motion{direction: step, repeat: repeat}
+		motions = append(motions, Motion{direction: step, repeat: repeat})
+	}
+	err = lines.Close()
+	if err != nil {
+		return nil, err
 	}
+	return motions, nil
 }
 
-func (r *Rope) Print() {
-	const size = 30
-	icons := make(map[Position]rune)
-	char := 'A'
-	link := r
-	for {
-		icons[link.Head] = char
-		char++
-		if link.Last() {
-			break
-		}
-		link = link.Next
-	}
-	fmt.Printf("\n::: Head at %v :::\n", r.Head)
-	for i := -size / 2; i < size/2; i++ {
-		for j := -size / 2; j < size/2; j++ {
-			var found bool
-			char, found = icons[Position{j, -i}]
-			if !found {
-				char = '.'
-			}
-			fmt.Printf(string(char))
-		}
-		fmt.Printf("\n")
-	}
+func (m Motions) Copy() Motions {
+	return append(Motions(nil), m...)
 }
 
-func ExecuteMoves(filename string, knots int) string {
-	motions := make(chan Motion)
-	go ReadSteps(filename, motions)
+var (
+	slack        = flag.Int("slack", 1, "max distance between neighboring knots before the follower moves")
+	metricName   = flag.String("metric", "chebyshev", "distance between knots: chebyshev (diagonal moves) or manhattan (orthogonal moves)")
+	printTraces  = flag.Bool("traces", false, "log number of positions visited by each knot")
+	renderFrames = flag.String("render", "", "print rope state for `frames` FROM:TO (half-open range, either end may be omitted) or a single frame")
+	heatOverlay  = flag.Bool("heat", false, "overlay tail trace as a heatmap when rendering")
+)
+
+func ExecuteMoves(motions Motions, knots int) string {
+	metric, err := ParseMetric(*metricName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *slack < 0 {
+		log.Fatalf("invalid slack: %d", *slack)
+	}
 
-	head, tail := NewRope(knots)
-	var debug bool
-	if knots == 10 && strings.HasSuffix(filename, "sample2.txt") {
-		debug = true
+	rope := NewRope(knots, Slack{Distance: *slack, Metric: metric})
+	var frames FrameRange
+	if *renderFrames != "" {
+		frames, err = ParseFrameRange(*renderFrames)
+		if err != nil {
+			log.Fatalf("invalid -render: %v", err)
+		}
+		rope.Recorder = NewRecorder(rope.Knots)
+	}
+	for _, motion := range motions {
+		rope.MoveN(motion.direction, motion.repeat)
+	}
+	if rope.Recorder != nil {
+		err = rope.Recorder.Render(os.Stdout, frames, *heatOverlay)
+		if err != nil {
+			log.Fatal(err)
+		}
 	}
-	for motion := range motions {
-		if debug {
-			head.Print()
+	if *printTraces {
+		for i, trace := range rope.Traces {
+			log.Printf("Knot %d visited %d positions", i, len(trace))
 		}
-		head.MoveN(motion.direction, motion.repeat)
 	}
-	return strconv.Itoa(len(tail.Trace))
+	return strconv.Itoa(len(rope.Trace()))
 }
 
-func part1(filename string) string {
-	return ExecuteMoves(filename, 2)
+func part1(motions Motions) string {
+	return ExecuteMoves(motions, 2)
 }
 
-func part2(filename string) string {
-	return ExecuteMoves(filename, 10)
+func part2(motions Motions) string {
+	return ExecuteMoves(motions, 10)
 }
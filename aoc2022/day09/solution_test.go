@@ -1,6 +1,7 @@
 package main
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -8,7 +9,7 @@ const sample = "sample.txt"
 
 func TestSample(t *testing.T) {
 	tests := []struct {
-		worker func(string) string
+		worker func(Motions) string
 		input  string
 		result string
 	}{
@@ -17,8 +18,18 @@ func TestSample(t *testing.T) {
 		{worker: part1, input: "sample2.txt", result: "88"},
 		{worker: part2, input: "sample2.txt", result: "36"},
 	}
+	parsed := make(map[string]Motions)
 	for i, test := range tests {
-		got := test.worker(test.input)
+		input, ok := parsed[test.input]
+		if !ok {
+			var err error
+			input, err = ReadMotions(test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			parsed[test.input] = input
+		}
+		got := test.worker(input.Copy())
 		expected := test.result
 		if got != expected {
 			t.Errorf("sample: part %d expected %q, got %q", i%2+1, expected, got)
@@ -26,14 +37,130 @@ func TestSample(t *testing.T) {
 	}
 }
 
+func TestFollowRules(t *testing.T) {
+	tests := []struct {
+		rule     Slack
+		leader   Position
+		follower Position
+		step     Direction
+		moves    bool
+	}{
+		{Slack{1, Chebyshev}, Position{1, 1}, Position{0, 0}, Direction{}, false},
+		{Slack{1, Chebyshev}, Position{2, 1}, Position{0, 0}, Direction{1, 1}, true},
+		{Slack{1, Manhattan}, Position{1, 1}, Position{0, 0}, Direction{1, 0}, true},
+		{Slack{1, Manhattan}, Position{1, -2}, Position{0, 0}, Direction{0, -1}, true},
+		{Slack{2, Chebyshev}, Position{-2, 2}, Position{0, 0}, Direction{}, false},
+		{Slack{2, Chebyshev}, Position{-3, 2}, Position{0, 0}, Direction{-1, 1}, true},
+		{Slack{0, Chebyshev}, Position{0, 1}, Position{0, 0}, Direction{0, 1}, true},
+	}
+	for _, test := range tests {
+		step, moves := test.rule.Follow(test.leader, test.follower)
+		if step != test.step || moves != test.moves {
+			t.Errorf("%v, leader at %v, follower at %v: want %v (%v), got %v (%v)", test.rule, test.leader, test.follower, test.step, test.moves, step, moves)
+		}
+	}
+}
+
+func TestRope(t *testing.T) {
+	rope := NewRope(3, Slack{1, Chebyshev})
+	rope.MoveN(stepDirections["UR"], 3)
+	rope.MoveN(stepDirections["D"], 2)
+	want := []Position{{3, 1}, {2, 2}, {1, 1}}
+	for i, knot := range rope.Knots {
+		if knot != want[i] {
+			t.Errorf("knot %d: want %v, got %v", i, want[i], knot)
+		}
+	}
+	visited := []int{6, 3, 2}
+	for i, trace := range rope.Traces {
+		if len(trace) != visited[i] {
+			t.Errorf("knot %d: want %d visited positions, got %d", i, visited[i], len(trace))
+		}
+	}
+
+	rope = NewRope(2, Slack{0, Manhattan})
+	rope.MoveN(stepDirections["DL"], 2)
+	if rope.Tail() != rope.Head() {
+		t.Errorf("rope without slack must fold into single point, got %v", rope.Knots)
+	}
+	if len(rope.Trace()) != 5 {
+		t.Errorf("tail must visit 5 positions on its orthogonal path, got %v", rope.Trace())
+	}
+}
+
 func BenchmarkPart1(b *testing.B) {
+	input, err := ReadMotions(sample)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		part1(sample)
+		part1(input.Copy())
 	}
 }
 
 func BenchmarkPart2(b *testing.B) {
+	input, err := ReadMotions(sample)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		part2(sample)
+		part2(input.Copy())
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	rope := NewRope(2, Slack{1, Chebyshev})
+	rope.Recorder = NewRecorder(rope.Knots)
+	motions, err := ReadMotions(sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var steps int
+	for _, motion := range motions {
+		rope.MoveN(motion.direction, motion.repeat)
+		steps += motion.repeat
+	}
+	frames := rope.Recorder.Frames
+	if len(frames) != steps+1 {
+		t.Fatalf("want %d frames, got %d", steps+1, len(frames))
+	}
+	want := ".TH\n...\ns.."
+	if got := rope.Recorder.Frame(len(frames) - 1); got != want {
+		t.Errorf("last frame: want\n%s\ngot\n%s", want, got)
+	}
+
+	var out strings.Builder
+	err = rope.Recorder.Render(&out, FrameRange{From: len(frames) - 1, To: -1}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 6 { // header and 5x5 grid covering the whole tail trace
+		t.Fatalf("unexpected heat overlay:\n%s", out.String())
+	}
+	var visited int
+	for _, line := range lines[1:] {
+		if len(line) != 5 {
+			t.Errorf("row width: want 5, got %d: %q", len(line), line)
+		}
+		visited += len(line) - strings.Count(line, ".")
+	}
+	marked := len(rope.Trace())
+	if !rope.Trace()[rope.Head()] {
+		marked++
+	}
+	if visited != marked {
+		t.Errorf("want %d marked positions, got %d:\n%s", marked, visited, out.String())
+	}
+
+	_, err = ParseFrameRange("x:1")
+	if err == nil {
+		t.Errorf("invalid frame range accepted")
+	}
+	err = rope.Recorder.Render(&out, FrameRange{From: len(frames), To: -1}, false)
+	if err == nil {
+		t.Errorf("empty frame range accepted")
 	}
 }
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+	"unicode/utf8"
+)
+
+// Screen which draws one pixel per cycle, sprite position is taken from
+// register X
+//
+// Pixels are drawn row by row. When the screen is full, the next cycle
+// starts a new frame.
+type CRT struct {
+	Width  int
+	Height int
+	Sprite int      // width of sprite in pixels
+	Lit    rune     // glyph for lit pixels
+	Dark   rune     // glyph for dark pixels
+	Frames [][]bool // pixels of every frame, the last one may be incomplete
+}
+
+// Create a screen, glyphs are the symbols for lit and dark pixels
+func NewCRT(width, height, sprite int, glyphs string) (*CRT, error) {
+	if width < 1 || height < 1 {
+		return nil, fmt.Errorf("invalid screen size: %dx%d", width, height)
+	}
+	if sprite < 1 {
+		return nil, fmt.Errorf("invalid sprite width: %d", sprite)
+	}
+	if utf8.RuneCountInString(glyphs) != 2 {
+		return nil, fmt.Errorf("expected two glyphs (lit and dark), got %q", glyphs)
+	}
+	lit, size := utf8.DecodeRuneInString(glyphs)
+	dark, _ := utf8.DecodeRuneInString(glyphs[size:])
+	return &CRT{
+		Width:  width,
+		Height: height,
+		Sprite: sprite,
+		Lit:    lit,
+		Dark:   dark,
+	}, nil
+}
+
+// Sprite covers Sprite pixels around X, extra pixel of even sprites goes
+// to the right
+func (crt *CRT) Observe(cpu *CPU) {
+	pixels := crt.Width * crt.Height
+	position := (cpu.Cycle - 1) % pixels
+	if position == 0 {
+		crt.Frames = append(crt.Frames, make([]bool, 0, pixels))
+	}
+	column := position % crt.Width
+	offset := column - cpu.Registers[X]
+	lit := offset >= -(crt.Sprite-1)/2 && offset <= crt.Sprite/2
+	frame := &crt.Frames[len(crt.Frames)-1]
+	*frame = append(*frame, lit)
+}
+
+// Render all frames with glyphs, frames are separated by empty lines
+func (crt *CRT) String() string {
+	var b strings.Builder
+	for _, frame := range crt.Frames {
+		for i, lit := range frame {
+			if lit {
+				b.WriteRune(crt.Lit)
+			} else {
+				b.WriteRune(crt.Dark)
+			}
+			if (i+1)%crt.Width == 0 {
+				b.WriteByte('\n')
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Render all frames one below another, each screen pixel takes
+// scale x scale image pixels
+//
+// Pixels which were not drawn (at the end of the last frame) are
+// transparent.
+func (crt *CRT) Image(scale int) image.Image {
+	if scale < 1 {
+		scale = 1
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, crt.Width*scale, crt.Height*len(crt.Frames)*scale))
+	for f, frame := range crt.Frames {
+		for i, lit := range frame {
+			c := color.NRGBA{A: 255}
+			if lit {
+				c = color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+			}
+			x := i % crt.Width * scale
+			y := (f*crt.Height + i/crt.Width) * scale
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetNRGBA(x+dx, y+dy, c)
+				}
+			}
+		}
+	}
+	return img
+}
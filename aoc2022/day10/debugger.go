@@ -0,0 +1,357 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"aoc2022/puzzle"
+)
+
+// Condition on CPU state which pauses execution when it becomes true
+type Breakpoint interface {
+	Hit(cpu *CPU) bool
+	String() string
+}
+
+// Pause when given cycle is over
+type CycleBreakpoint int
+
+func (b CycleBreakpoint) Hit(cpu *CPU) bool {
+	return cpu.Cycle == int(b)
+}
+
+func (b CycleBreakpoint) String() string {
+	return fmt.Sprintf("cycle %d", int(b))
+}
+
+// Pause before executing instruction at given address
+type AddressBreakpoint int
+
+func (b AddressBreakpoint) Hit(cpu *CPU) bool {
+	_, current := cpu.Current()
+	return current == nil && cpu.PC == int(b)
+}
+
+func (b AddressBreakpoint) String() string {
+	return fmt.Sprintf("pc %d", int(b))
+}
+
+// Pause when register value satisfies the comparison
+type RegisterBreakpoint struct {
+	Register Register
+	Operator string
+	Value    int
+}
+
+var comparisons = map[string]func(a, b int) bool{
+	"==": func(a, b int) bool { return a == b },
+	"!=": func(a, b int) bool { return a != b },
+	"<":  func(a, b int) bool { return a < b },
+	"<=": func(a, b int) bool { return a <= b },
+	">":  func(a, b int) bool { return a > b },
+	">=": func(a, b int) bool { return a >= b },
+}
+
+func (b RegisterBreakpoint) Hit(cpu *CPU) bool {
+	return comparisons[b.Operator](cpu.Registers[b.Register], b.Value)
+}
+
+func (b RegisterBreakpoint) String() string {
+	return fmt.Sprintf("%v%s%d", b.Register, b.Operator, b.Value)
+}
+
+// Parse breakpoint specification:
+//
+//	cycle N    when cycle N is over
+//	pc N       before instruction at address N (zero based)
+//	REG OP N   when register comparison becomes true, OP is one of
+//	           == != < <= > >=, spaces around OP are optional
+func ParseBreakpoint(spec string) (Breakpoint, error) {
+	words := strings.Fields(spec)
+	if len(words) == 2 && (words[0] == "cycle" || words[0] == "pc") {
+		n, err := strconv.Atoi(words[1])
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid %s: %s", words[0], words[1])
+		}
+		if words[0] == "cycle" {
+			return CycleBreakpoint(n), nil
+		}
+		return AddressBreakpoint(n), nil
+	}
+	expr := strings.Join(words, "")
+	index := strings.IndexAny(expr, "=!<>")
+	if index < 0 {
+		return nil, fmt.Errorf("invalid breakpoint: %q", spec)
+	}
+	register, err := ParseRegister(expr[:index])
+	if err != nil {
+		return nil, err
+	}
+	operator := strings.TrimRight(expr[index:], "-0123456789")
+	if _, ok := comparisons[operator]; !ok {
+		return nil, fmt.Errorf("invalid comparison: %q", operator)
+	}
+	value, err := strconv.Atoi(expr[index+len(operator):])
+	if err != nil {
+		return nil, fmt.Errorf("invalid value: %q", expr[index+len(operator):])
+	}
+	return RegisterBreakpoint{register, operator, value}, nil
+}
+
+// State of the CPU during a single cycle
+type TraceRow struct {
+	Cycle       int
+	Address     int
+	Instruction string
+	Registers   [registerCount]int
+}
+
+// Observer which records every cycle
+type Trace struct {
+	Rows []TraceRow
+}
+
+func (t *Trace) Observe(cpu *CPU) {
+	address, instruction := cpu.Current()
+	t.Rows = append(t.Rows, TraceRow{
+		Cycle:       cpu.Cycle,
+		Address:     address,
+		Instruction: instruction.String(),
+		Registers:   cpu.Registers,
+	})
+}
+
+// Export trace as a table in CSV format, one cycle per row
+func (t *Trace) WriteCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	header := []string{"cycle", "pc", "instruction"}
+	for r := Register(0); r < registerCount; r++ {
+		header = append(header, r.String())
+	}
+	err := out.Write(header)
+	if err != nil {
+		return err
+	}
+	record := make([]string, len(header))
+	for _, row := range t.Rows {
+		record[0] = strconv.Itoa(row.Cycle)
+		record[1] = strconv.Itoa(row.Address)
+		record[2] = row.Instruction
+		for i, value := range row.Registers {
+			record[3+i] = strconv.Itoa(value)
+		}
+		err = out.Write(record)
+		if err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+type Debugger struct {
+	CPU         *CPU
+	Breakpoints []Breakpoint
+	Watches     []Register // registers shown on every pause, all if empty
+	Trace       *Trace
+}
+
+// Attach debugger to CPU before the program starts
+func NewDebugger(cpu *CPU) *Debugger {
+	trace := &Trace{}
+	cpu.Observers = append(cpu.Observers, trace)
+	return &Debugger{CPU: cpu, Trace: trace}
+}
+
+// Run one cycle, report breakpoint if its condition has become true
+func (d *Debugger) Tick() (Breakpoint, error) {
+	before := make([]bool, len(d.Breakpoints))
+	for i, bp := range d.Breakpoints {
+		before[i] = bp.Hit(d.CPU)
+	}
+	err := d.CPU.Tick()
+	if err != nil {
+		return nil, err
+	}
+	for i, bp := range d.Breakpoints {
+		if !before[i] && bp.Hit(d.CPU) {
+			return bp, nil
+		}
+	}
+	return nil, nil
+}
+
+// Run given number of cycles, stop early on breakpoints
+func (d *Debugger) Step(cycles int) (Breakpoint, error) {
+	for i := 0; i < cycles && !d.CPU.Done(); i++ {
+		bp, err := d.Tick()
+		if bp != nil || err != nil {
+			return bp, err
+		}
+	}
+	return nil, nil
+}
+
+// Finish current instruction or execute the next one
+func (d *Debugger) Next() (Breakpoint, error) {
+	for !d.CPU.Done() {
+		bp, err := d.Tick()
+		if bp != nil || err != nil {
+			return bp, err
+		}
+		if _, current := d.CPU.Current(); current == nil {
+			break
+		}
+	}
+	return nil, nil
+}
+
+// Run until breakpoint or until the program is over
+func (d *Debugger) Continue() (Breakpoint, error) {
+	for !d.CPU.Done() {
+		bp, err := d.Tick()
+		if bp != nil || err != nil {
+			return bp, err
+		}
+	}
+	return nil, nil
+}
+
+// Current state of the CPU and the watched registers
+func (d *Debugger) Status() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "cycle %d", d.CPU.Cycle)
+	if address, current := d.CPU.Current(); current != nil {
+		fmt.Fprintf(&b, ", executing %d: %v", address, current)
+	} else if d.CPU.Done() {
+		b.WriteString(", program is over")
+	} else {
+		fmt.Fprintf(&b, ", next %d: %v", d.CPU.PC, d.CPU.Program.Code[d.CPU.PC])
+	}
+	watches := d.Watches
+	if len(watches) == 0 {
+		for r := Register(0); r < registerCount; r++ {
+			watches = append(watches, r)
+		}
+	}
+	for _, r := range watches {
+		fmt.Fprintf(&b, ", %v=%d", r, d.CPU.Registers[r])
+	}
+	return b.String()
+}
+
+const debuggerHelp = `commands:
+  break SPEC      add breakpoint: cycle N, pc N or REG OP N (e.g. x>=20)
+  delete N        remove breakpoint by its number
+  info            list breakpoints and watches
+  watch REG       show register on every pause
+  step [N]        run N cycles (default: 1)
+  next            finish current instruction or execute the next one
+  continue        run until breakpoint or the end of program
+  print           show CPU state
+  trace FILE      save cycle by cycle trace in CSV format
+  quit            stop debugging and let the program finish`
+
+// Interactive debugging session, commands are read line by line from in
+func (d *Debugger) Run(in io.Reader, out io.Writer) error {
+	fmt.Fprintln(out, d.Status())
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(out, "(debug) ")
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return scanner.Err()
+		}
+		words := strings.Fields(scanner.Text())
+		if len(words) == 0 {
+			continue
+		}
+		if words[0] == "quit" || words[0] == "q" {
+			return nil
+		}
+		err := d.command(words[0], words[1:], out)
+		if err != nil {
+			fmt.Fprintf(out, "error: %v\n", err)
+		}
+	}
+}
+
+func (d *Debugger) command(name string, args []string, out io.Writer) error {
+	var bp Breakpoint
+	var err error
+	switch name {
+	case "break", "b":
+		bp, err = ParseBreakpoint(strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		d.Breakpoints = append(d.Breakpoints, bp)
+		fmt.Fprintf(out, "breakpoint %d: %v\n", len(d.Breakpoints), bp)
+		return nil
+	case "delete", "d":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: delete N")
+		}
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 || n > len(d.Breakpoints) {
+			return fmt.Errorf("no such breakpoint: %s", args[0])
+		}
+		d.Breakpoints = append(d.Breakpoints[:n-1], d.Breakpoints[n:]...)
+		return nil
+	case "info", "i":
+		for i, bp := range d.Breakpoints {
+			fmt.Fprintf(out, "breakpoint %d: %v\n", i+1, bp)
+		}
+		for _, r := range d.Watches {
+			fmt.Fprintf(out, "watch: %v\n", r)
+		}
+		return nil
+	case "watch", "w":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: watch REG")
+		}
+		r, err := ParseRegister(args[0])
+		if err != nil {
+			return err
+		}
+		d.Watches = append(d.Watches, r)
+		return nil
+	case "step", "s":
+		cycles := 1
+		if len(args) == 1 {
+			cycles, err = strconv.Atoi(args[0])
+			if err != nil || cycles < 1 {
+				return fmt.Errorf("invalid number of cycles: %s", args[0])
+			}
+		}
+		bp, err = d.Step(cycles)
+	case "next", "n":
+		bp, err = d.Next()
+	case "continue", "c":
+		bp, err = d.Continue()
+	case "print", "p":
+	case "trace":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: trace FILE")
+		}
+		err = puzzle.WriteFile(args[0], d.Trace.WriteCSV)
+		if err == nil {
+			fmt.Fprintf(out, "saved %d cycles to %s\n", len(d.Trace.Rows), args[0])
+		}
+		return err
+	case "help", "h":
+		fmt.Fprintln(out, debuggerHelp)
+		return nil
+	default:
+		return fmt.Errorf("unknown command: %s (try help)", name)
+	}
+	if bp != nil {
+		fmt.Fprintf(out, "breakpoint: %v\n", bp)
+	}
+	fmt.Fprintln(out, d.Status())
+	return err
+}
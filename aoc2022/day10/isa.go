@@ -0,0 +1,319 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"aoc2022/puzzle"
+)
+
+type Register uint8
+
+const (
+	X Register = iota
+	Y
+	Z
+	W
+	registerCount
+)
+
+var registerNames = [registerCount]string{"x", "y", "z", "w"}
+
+func (r Register) String() string {
+	if r >= registerCount {
+		return fmt.Sprintf("r%d", r)
+	}
+	return registerNames[r]
+}
+
+func ParseRegister(name string) (Register, error) {
+	for i, known := range registerNames {
+		if name == known {
+			return Register(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown register: %s", name)
+}
+
+type OperandKind uint8
+
+const (
+	RegisterOperand OperandKind = iota // register name
+	ValueOperand                       // integer or register name
+	LabelOperand                       // jump target
+)
+
+func (k OperandKind) String() string {
+	switch k {
+	case RegisterOperand:
+		return "REG"
+	case ValueOperand:
+		return "VAL"
+	case LabelOperand:
+		return "LABEL"
+	}
+	return fmt.Sprintf("OperandKind(%d)", k)
+}
+
+type Operand struct {
+	Kind         OperandKind
+	Register     Register // for registers and values read from registers
+	FromRegister bool     // value operand refers to a register
+	Value        int      // immediate value or address of the label
+	Text         string   // as written in the source
+}
+
+// Description of a CPU instruction
+//
+// Exec is called when the last cycle of instruction is over. Program
+// counter already points to the next instruction at that time, jumps
+// overwrite it.
+type Opcode struct {
+	Name     string
+	Cycles   int
+	Operands []OperandKind
+	Exec     func(cpu *CPU, args []Operand) error
+}
+
+func (op *Opcode) Usage() string {
+	words := []string{op.Name}
+	for _, kind := range op.Operands {
+		words = append(words, kind.String())
+	}
+	return strings.Join(words, " ")
+}
+
+// Instruction set of the CPU, keyed by mnemonic
+var Opcodes = map[string]*Opcode{}
+
+func defineOpcode(name string, cycles int, operands []OperandKind, exec func(*CPU, []Operand) error) {
+	if _, exists := Opcodes[name]; exists {
+		panic("duplicate opcode: " + name)
+	}
+	Opcodes[name] = &Opcode{Name: name, Cycles: cycles, Operands: operands, Exec: exec}
+}
+
+func arithmetic(apply func(a, b int) (int, error)) func(*CPU, []Operand) error {
+	return func(cpu *CPU, args []Operand) error {
+		result, err := apply(cpu.Registers[args[0].Register], cpu.Load(args[1]))
+		if err != nil {
+			return err
+		}
+		cpu.Registers[args[0].Register] = result
+		return nil
+	}
+}
+
+func branch(condition func(value int) bool) func(*CPU, []Operand) error {
+	return func(cpu *CPU, args []Operand) error {
+		if condition(cpu.Load(args[0])) {
+			cpu.PC = args[1].Value
+		}
+		return nil
+	}
+}
+
+func init() {
+	var (
+		none    = []OperandKind{}
+		value   = []OperandKind{ValueOperand}
+		binary  = []OperandKind{RegisterOperand, ValueOperand}
+		label   = []OperandKind{LabelOperand}
+		compare = []OperandKind{ValueOperand, LabelOperand}
+	)
+	defineOpcode("noop", 1, none, func(*CPU, []Operand) error { return nil })
+	defineOpcode("halt", 1, none, func(cpu *CPU, _ []Operand) error {
+		cpu.Halted = true
+		return nil
+	})
+
+	// Puzzle instruction addx and its siblings for other registers
+	for r := Register(0); r < registerCount; r++ {
+		register := r
+		defineOpcode("add"+register.String(), 2, value, func(cpu *CPU, args []Operand) error {
+			cpu.Registers[register] += cpu.Load(args[0])
+			return nil
+		})
+	}
+
+	defineOpcode("set", 1, binary, arithmetic(func(_, b int) (int, error) { return b, nil }))
+	defineOpcode("add", 2, binary, arithmetic(func(a, b int) (int, error) { return a + b, nil }))
+	defineOpcode("sub", 2, binary, arithmetic(func(a, b int) (int, error) { return a - b, nil }))
+	defineOpcode("mul", 3, binary, arithmetic(func(a, b int) (int, error) { return a * b, nil }))
+	defineOpcode("mod", 3, binary, arithmetic(func(a, b int) (int, error) {
+		if b == 0 {
+			return 0, fmt.Errorf("modulo by zero")
+		}
+		return a % b, nil
+	}))
+
+	defineOpcode("jmp", 1, label, func(cpu *CPU, args []Operand) error {
+		cpu.PC = args[0].Value
+		return nil
+	})
+	defineOpcode("jz", 1, compare, branch(func(v int) bool { return v == 0 }))
+	defineOpcode("jnz", 1, compare, branch(func(v int) bool { return v != 0 }))
+	defineOpcode("jgz", 1, compare, branch(func(v int) bool { return v > 0 }))
+	defineOpcode("jlz", 1, compare, branch(func(v int) bool { return v < 0 }))
+}
+
+// Single parsed line of the program
+type Instruction struct {
+	Op   *Opcode
+	Args []Operand
+	Line int // in source file
+}
+
+func (i Instruction) String() string {
+	words := []string{i.Op.Name}
+	for _, arg := range i.Args {
+		words = append(words, arg.Text)
+	}
+	return strings.Join(words, " ")
+}
+
+type Program struct {
+	Code   []Instruction
+	Labels map[string]int // label name to instruction address
+}
+
+// Parse program source, one instruction per line
+//
+// Labels are declared on separate lines as "name:" and point to the next
+// instruction. Empty lines and comments starting with '#' are ignored.
+func ParseProgram(lines <-chan string) (*Program, error) {
+	program := &Program{Labels: make(map[string]int)}
+	type reference struct {
+		address, arg int
+	}
+	var pending []reference
+	var lineNo int
+	for line := range lines {
+		lineNo++
+		if index := strings.IndexByte(line, '#'); index >= 0 {
+			line = line[:index]
+		}
+		words := strings.Fields(line)
+		if len(words) == 0 {
+			continue
+		}
+		if len(words) == 1 && strings.HasSuffix(words[0], ":") {
+			name := strings.TrimSuffix(words[0], ":")
+			if !validLabel(name) {
+				return nil, fmt.Errorf("line %d: invalid label name: %q", lineNo, name)
+			}
+			if _, exists := program.Labels[name]; exists {
+				return nil, fmt.Errorf("line %d: duplicate label: %s", lineNo, name)
+			}
+			program.Labels[name] = len(program.Code)
+			continue
+		}
+		instruction, err := parseInstruction(words)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		instruction.Line = lineNo
+		for i, arg := range instruction.Args {
+			if arg.Kind == LabelOperand {
+				pending = append(pending, reference{len(program.Code), i})
+			}
+		}
+		program.Code = append(program.Code, instruction)
+	}
+	for _, ref := range pending {
+		instruction := program.Code[ref.address]
+		arg := &instruction.Args[ref.arg]
+		address, ok := program.Labels[arg.Text]
+		if !ok {
+			return nil, fmt.Errorf("line %d: undefined label: %s", instruction.Line, arg.Text)
+		}
+		arg.Value = address
+	}
+	return program, nil
+}
+
+// Read program source from file
+func ReadProgram(filename string) (*Program, error) {
+	lines, err := puzzle.ReadLines(filename)
+	if err != nil {
+		return nil, err
+	}
+	program, err := ParseProgram(lines.C)
+	if closeErr := lines.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return program, nil
+}
+
+// Deep copy, opcodes are shared since they never change
+func (p *Program) Copy() *Program {
+	clone := &Program{
+		Code:   make([]Instruction, len(p.Code)),
+		Labels: make(map[string]int, len(p.Labels)),
+	}
+	for i, instruction := range p.Code {
+		instruction.Args = append([]Operand(nil), instruction.Args...)
+		clone.Code[i] = instruction
+	}
+	for name, address := range p.Labels {
+		clone.Labels[name] = address
+	}
+	return clone
+}
+
+func parseInstruction(words []string) (instruction Instruction, err error) {
+	op, ok := Opcodes[words[0]]
+	if !ok {
+		return instruction, fmt.Errorf("invalid CPU instruction: %s", words[0])
+	}
+	args := words[1:]
+	if len(args) != len(op.Operands) {
+		return instruction, fmt.Errorf("%s takes %d operands, got %d (usage: %s)", op.Name, len(op.Operands), len(args), op.Usage())
+	}
+	instruction.Op = op
+	instruction.Args = make([]Operand, len(args))
+	for i, kind := range op.Operands {
+		arg := Operand{Kind: kind, Text: args[i]}
+		switch kind {
+		case RegisterOperand:
+			arg.Register, err = ParseRegister(args[i])
+		case ValueOperand:
+			arg.Value, err = strconv.Atoi(args[i])
+			if err != nil {
+				arg.Register, err = ParseRegister(args[i])
+				arg.FromRegister = true
+				if err != nil {
+					err = fmt.Errorf("neither a number nor a register: %s", args[i])
+				}
+			}
+		case LabelOperand:
+			if !validLabel(args[i]) {
+				err = fmt.Errorf("invalid label name: %q", args[i])
+			}
+		}
+		if err != nil {
+			return instruction, fmt.Errorf("%s: %w", op.Name, err)
+		}
+		instruction.Args[i] = arg
+	}
+	return instruction, nil
+}
+
+func validLabel(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, char := range name {
+		switch {
+		case char == '_', char >= 'a' && char <= 'z', char >= 'A' && char <= 'Z':
+		case i > 0 && char >= '0' && char <= '9':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -1,132 +1,196 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"image/png"
+	"io"
 	"log"
+	"os"
 	"strconv"
-	"strings"
+
+	"aoc2022/puzzle"
 )
 
-type Instruction int8
+// Observers are notified during every cycle of the CPU
+type Observer interface {
+	Observe(cpu *CPU)
+}
+
+type ObserverFunc func(cpu *CPU)
 
-const (
-	Noop Instruction = 1 << iota
-	Addx
-)
+func (f ObserverFunc) Observe(cpu *CPU) {
+	f(cpu)
+}
 
 type CPU struct {
-	X      int
-	Cycle  int
-	Result int
-	Output strings.Builder
+	Registers [registerCount]int
+	Cycle     int // number of the current (or the last finished) cycle
+	PC        int // address of the next instruction
+	Halted    bool
+	Program   *Program
+	Observers []Observer
+
+	current   *Instruction // in flight
+	address   int          // of current instruction
+	remaining int          // cycles until current instruction is over
+}
+
+func NewCPU(program *Program, observers ...Observer) *CPU {
+	cpu := &CPU{Program: program, Observers: observers}
+	cpu.Registers[X] = 1
+	return cpu
 }
 
-func (cpu *CPU) Execute(op Instruction, arg ...int) {
-	switch op {
-	default:
-		panic(fmt.Sprintf("unsupported instruction: %b", op))
-	case Noop:
-		cpu.noop(arg)
-	case Addx:
-		cpu.addx(arg)
+// Value of operand: register contents or immediate value
+func (cpu *CPU) Load(arg Operand) int {
+	if arg.Kind == RegisterOperand || arg.FromRegister {
+		return cpu.Registers[arg.Register]
 	}
+	return arg.Value
 }
 
-func (cpu *CPU) Tick() {
-	cpu.Draw()
-	cpu.Cycle++
-	if (cpu.Cycle-20)%40 == 0 && cpu.Cycle <= 220 {
-		//fmt.Printf("cycle %d, register X: %d\n", cpu.Cycle, cpu.X)
-		cpu.Result += cpu.Cycle * cpu.X
+// Instruction in flight and its address, nil between instructions
+func (cpu *CPU) Current() (int, *Instruction) {
+	return cpu.address, cpu.current
+}
+
+// Program is over: CPU has halted or has run out of instructions
+func (cpu *CPU) Done() bool {
+	if cpu.current != nil {
+		return false
 	}
+	return cpu.Halted || cpu.PC < 0 || cpu.PC >= len(cpu.Program.Code)
 }
 
-func (cpu *CPU) Draw() {
-	symbol := map[bool]rune{
-		true:  '#',
-		false: '.',
+// Run a single cycle
+//
+// Observers see register values as they are during the cycle: instruction
+// effects are applied after its last cycle is over.
+func (cpu *CPU) Tick() error {
+	if cpu.Done() {
+		return fmt.Errorf("program is over")
 	}
-	//fmt.Printf(
-	//	"cycle %d, regX value %d, output %c\n",
-	//	cpu.Cycle+1,
-	//	cpu.X,
-	//	symbol[abs(cpu.X - cpu.Cycle%40) <= 1],
-	//)
-	cpu.Output.WriteRune(
-		symbol[abs(cpu.X-cpu.Cycle%40) <= 1],
-	)
-	if (cpu.Cycle+1)%40 == 0 {
-		cpu.Output.WriteString("\n")
+	if cpu.current == nil {
+		cpu.current = &cpu.Program.Code[cpu.PC]
+		cpu.address = cpu.PC
+		cpu.remaining = cpu.current.Op.Cycles
+		cpu.PC++
 	}
-
-	if (cpu.Cycle+1)%(40*6) == 0 {
-		cpu.Output.WriteString("\n")
+	cpu.Cycle++
+	for _, observer := range cpu.Observers {
+		observer.Observe(cpu)
+	}
+	cpu.remaining--
+	if cpu.remaining > 0 {
+		return nil
+	}
+	instruction := cpu.current
+	cpu.current = nil
+	err := instruction.Op.Exec(cpu, instruction.Args)
+	if err != nil {
+		return fmt.Errorf("cycle %d, line %d (%v): %w", cpu.Cycle, instruction.Line, instruction, err)
 	}
+	return nil
 }
 
-func abs(num int) int {
-	if num < 0 {
-		return num * -1
+// Finish current instruction or execute the next one
+func (cpu *CPU) Step() error {
+	for {
+		err := cpu.Tick()
+		if err != nil || cpu.current == nil {
+			return err
+		}
 	}
-	return num
 }
 
-func (cpu *CPU) noop(arg []int) {
-	if len(arg) != 0 {
-		panic("noop instruction takes no arguments")
+// Run program until it's over, limit is the maximum number of cycles
+// (zero means unlimited)
+func (cpu *CPU) Run(limit int) error {
+	for !cpu.Done() {
+		if limit > 0 && cpu.Cycle >= limit {
+			return fmt.Errorf("program did not finish in %d cycles", limit)
+		}
+		err := cpu.Tick()
+		if err != nil {
+			return err
+		}
 	}
-	cpu.Tick()
+	return nil
+}
+
+// Sum of signal strengths during cycles 20, 60, 100, ...
+type SignalStrength struct {
+	Sum int
 }
 
-func (cpu *CPU) addx(arg []int) {
-	if len(arg) != 1 {
-		panic("addx instruction takes no exactly one argument")
+func (s *SignalStrength) Observe(cpu *CPU) {
+	if (cpu.Cycle-20)%40 == 0 && cpu.Cycle <= 220 {
+		s.Sum += cpu.Cycle * cpu.Registers[X]
 	}
-	cpu.Tick()
-	cpu.Tick()
-	cpu.X += arg[0]
 }
 
-func Execute(script string) *CPU {
-	cpu := CPU{X: 1}
-	var command, value string
-	var parsed int
-	var found bool
-	var err error
-	var arg []int
-	var op Instruction
+var (
+	maxCycles = flag.Int("cycles", 1_000_000, "stop programs which run longer than that many `cycles` (0 means no limit)")
+	debug     = flag.Bool("debug", false, "start interactive debugger, commands are read from stdin")
+	traceFile = flag.String("trace", "", "save cycle by cycle trace to `file` in CSV format")
 
-	opcode := map[string]Instruction{
-		"addx": Addx,
-		"noop": Noop,
-	}
-	for line := range ReadLines(script) {
-		command, value, found = strings.Cut(line, " ")
-		if !found {
-			command = line
-			arg = []int{}
-		} else {
-			parsed, err = strconv.Atoi(value)
-			if err != nil {
-				log.Fatalf("could not parse command argument: %s", line)
-			}
-			arg = []int{parsed}
+	screenWidth  = flag.Int("width", 40, "CRT screen width in pixels")
+	screenHeight = flag.Int("height", 6, "CRT screen height in pixels")
+	spriteWidth  = flag.Int("sprite", 3, "sprite width in pixels")
+	glyphs       = flag.String("glyphs", "#.", "symbols for lit and dark pixels")
+	pngFile      = flag.String("png", "", "save CRT frames to `file` in PNG format")
+	pngScale     = flag.Int("scale", 1, "size of CRT pixel in PNG image")
+)
+
+func Execute(program *Program, observers ...Observer) *CPU {
+	cpu := NewCPU(program, observers...)
+	var trace *Trace
+	var err error
+	if *debug {
+		debugger := NewDebugger(cpu)
+		trace = debugger.Trace
+		err = debugger.Run(os.Stdin, os.Stdout)
+		if err != nil {
+			log.Fatal(err)
 		}
-		op, found = opcode[command]
-		if !found {
-			log.Fatalf("invalid CPU instruction: %s", command)
+	} else if *traceFile != "" {
+		trace = &Trace{}
+		cpu.Observers = append(cpu.Observers, trace)
+	}
+	err = cpu.Run(*maxCycles)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *traceFile != "" {
+		err = puzzle.WriteFile(*traceFile, trace.WriteCSV)
+		if err != nil {
+			log.Fatal(err)
 		}
-		cpu.Execute(op, arg...)
 	}
-	return &cpu
+	return cpu
 }
 
-func part1(filename string) string {
-	cpu := Execute(filename)
-	return strconv.Itoa(cpu.Result)
+func part1(program *Program) string {
+	signal := &SignalStrength{}
+	Execute(program, signal)
+	return strconv.Itoa(signal.Sum)
 }
 
-func part2(filename string) string {
-	cpu := Execute(filename)
-	return cpu.Output.String()
+func part2(program *Program) string {
+	crt, err := NewCRT(*screenWidth, *screenHeight, *spriteWidth, *glyphs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	Execute(program, crt)
+	if *pngFile != "" {
+		img := crt.Image(*pngScale)
+		err = puzzle.WriteFile(*pngFile, func(w io.Writer) error {
+			return png.Encode(w, img)
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	return crt.String()
 }
@@ -18,15 +18,25 @@ const part2result = `
 
 func TestSample(t *testing.T) {
 	tests := []struct {
-		worker func(string) string
+		worker func(*Program) string
 		input  string
 		result string
 	}{
 		{worker: part1, input: sample, result: "13140"},
 		{worker: part2, input: sample, result: part2result},
 	}
+	parsed := make(map[string]*Program)
 	for i, test := range tests {
-		got := strings.TrimSpace(test.worker(test.input))
+		input, ok := parsed[test.input]
+		if !ok {
+			var err error
+			input, err = ReadProgram(test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			parsed[test.input] = input
+		}
+		got := strings.TrimSpace(test.worker(input.Copy()))
 		expected := strings.TrimSpace(test.result)
 		if got != expected {
 			t.Errorf("sample: part %d expected %q, got %q", i%2+1, expected, got)
@@ -47,14 +57,238 @@ func TestSample(t *testing.T) {
 	}
 }
 
+func parseLines(lines ...string) (*Program, error) {
+	input := make(chan string)
+	go func() {
+		for _, line := range lines {
+			input <- line
+		}
+		close(input)
+	}()
+	return ParseProgram(input)
+}
+
+func TestProgram(t *testing.T) {
+	program, err := parseLines(
+		"# factorial of z goes to y",
+		"set y 1",
+		"set z 5",
+		"loop:",
+		"  mul y z",
+		"  sub z 1",
+		"  jnz z loop",
+		"halt",
+		"addx 100 # never reached",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cycles int
+	cpu := NewCPU(program, ObserverFunc(func(*CPU) { cycles++ }))
+	err = cpu.Run(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cpu.Registers[Y] != 120 || cpu.Registers[X] != 1 {
+		t.Errorf("unexpected registers after run: %v", cpu.Registers)
+	}
+	if cycles != 33 || cpu.Cycle != 33 {
+		t.Errorf("want 33 cycles, observed %d (counter %d)", cycles, cpu.Cycle)
+	}
+	if !cpu.Halted || !cpu.Done() {
+		t.Errorf("program did not halt")
+	}
+
+	cpu = NewCPU(program)
+	cpu.Step()
+	cpu.Step()
+	cpu.Step()
+	if cpu.Cycle != 5 || cpu.Registers[Y] != 5 || cpu.PC != 3 {
+		t.Errorf("after three steps: cycle %d, registers %v, PC %d", cpu.Cycle, cpu.Registers, cpu.PC)
+	}
+
+	program, err = parseLines("top:", "jmp top")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = NewCPU(program).Run(10)
+	if err == nil {
+		t.Errorf("endless loop was not stopped")
+	}
+}
+
+func TestProgramErrors(t *testing.T) {
+	tests := []struct {
+		lines []string
+		err   string
+	}{
+		{[]string{"noop", "jmp nowhere"}, "line 2: undefined label: nowhere"},
+		{[]string{"a:", "a:"}, "line 2: duplicate label: a"},
+		{[]string{"addx"}, "line 1: addx takes 1 operands, got 0 (usage: addx VAL)"},
+		{[]string{"set 1 1"}, "line 1: set: unknown register: 1"},
+		{[]string{"addx q"}, "line 1: addx: neither a number nor a register: q"},
+		{[]string{"push 1"}, "line 1: invalid CPU instruction: push"},
+	}
+	for _, test := range tests {
+		_, err := parseLines(test.lines...)
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%q: want error %q, got %v", test.lines, test.err, err)
+		}
+	}
+
+	program, _ := parseLines("mod x y")
+	err := NewCPU(program).Run(0)
+	if err == nil || !strings.Contains(err.Error(), "modulo by zero") {
+		t.Errorf("want modulo by zero, got %v", err)
+	}
+}
+
+func TestDebugger(t *testing.T) {
+	program, err := parseLines(
+		"set y 1",
+		"set z 5",
+		"loop:",
+		"mul y z",
+		"sub z 1",
+		"jnz z loop",
+		"halt",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	debugger := NewDebugger(NewCPU(program))
+	for _, spec := range []string{"y >= 20", "pc 5", "cycle 2"} {
+		bp, err := ParseBreakpoint(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		debugger.Breakpoints = append(debugger.Breakpoints, bp)
+	}
+	stops := []struct {
+		bp    string
+		cycle int
+	}{
+		{"cycle 2", 2},
+		{"y>=20", 11},
+		{"pc 5", 32},
+		{"", 33},
+	}
+	for _, stop := range stops {
+		bp, err := debugger.Continue()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var name string
+		if bp != nil {
+			name = bp.String()
+		}
+		if name != stop.bp || debugger.CPU.Cycle != stop.cycle {
+			t.Errorf("want stop at %q on cycle %d, got %q on cycle %d", stop.bp, stop.cycle, name, debugger.CPU.Cycle)
+		}
+	}
+	if len(debugger.Trace.Rows) != 33 {
+		t.Errorf("want 33 rows in trace, got %d", len(debugger.Trace.Rows))
+	}
+	row := debugger.Trace.Rows[10]
+	if row.Address != 2 || row.Instruction != "mul y z" || row.Registers[Y] != 5 {
+		t.Errorf("unexpected trace row: %+v", row)
+	}
+
+	for _, spec := range []string{"x", "q==1", "x=>1", "x==1a", "cycle -1"} {
+		_, err = ParseBreakpoint(spec)
+		if err == nil {
+			t.Errorf("invalid breakpoint accepted: %q", spec)
+		}
+	}
+}
+
+func TestDebuggerSession(t *testing.T) {
+	program, err := parseLines("addx 3", "addx -5", "noop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	debugger := NewDebugger(NewCPU(program))
+	var out strings.Builder
+	err = debugger.Run(strings.NewReader("watch x\nstep\nnext\nb x<0\ncontinue\nquit\n"), &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"cycle 0, next 0: addx 3, x=1, y=0, z=0, w=0",
+		"(debug) (debug) cycle 1, executing 0: addx 3, x=1",
+		"(debug) cycle 2, next 1: addx -5, x=4",
+		"(debug) breakpoint 1: x<0",
+		"(debug) breakpoint: x<0",
+		"cycle 4, next 2: noop, x=-1",
+		"(debug) ",
+	}
+	if got := out.String(); got != strings.Join(want, "\n") {
+		t.Errorf("unexpected session output:\n%s", got)
+	}
+}
+
+func TestCRT(t *testing.T) {
+	program, err := parseLines("noop", "addx 3", "noop", "addx -2", "noop", "noop", "noop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	crt, err := NewCRT(4, 2, 2, "@_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = NewCPU(program, crt).Run(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// sprite covers columns X and X+1: X=1 for three cycles, X=4 for
+	// three cycles and X=2 until the end, the last cycle starts a new frame
+	want := "_@@_\n__@@\n\n_\n"
+	if got := crt.String(); got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+
+	img := crt.Image(2)
+	if size := img.Bounds().Size(); size.X != 8 || size.Y != 8 {
+		t.Errorf("want 8x8 image, got %v", size)
+	}
+	for _, pixel := range []struct {
+		x, y int
+		lit  bool
+	}{{0, 0, false}, {3, 1, true}, {5, 3, true}, {1, 5, false}, {2, 4, false}} {
+		r, _, _, _ := img.At(pixel.x, pixel.y).RGBA()
+		if (r != 0) != pixel.lit {
+			t.Errorf("pixel %d,%d: want lit=%v", pixel.x, pixel.y, pixel.lit)
+		}
+	}
+	if _, _, _, a := img.At(7, 7).RGBA(); a != 0 {
+		t.Errorf("pixel beyond the last cycle is not transparent")
+	}
+
+	for _, glyphs := range []string{"#", "#.-", ""} {
+		if _, err := NewCRT(40, 6, 3, glyphs); err == nil {
+			t.Errorf("invalid glyphs accepted: %q", glyphs)
+		}
+	}
+}
+
 func BenchmarkPart1(b *testing.B) {
+	input, err := ReadProgram(sample)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		part1(sample)
+		part1(input.Copy())
 	}
 }
 
 func BenchmarkPart2(b *testing.B) {
+	input, err := ReadProgram(sample)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		part2(sample)
+		part2(input.Copy())
 	}
 }
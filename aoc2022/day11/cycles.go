@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Worry level and owner of an item at the start of a round
+type itemState struct {
+	value int64
+	owner int
+}
+
+// Play given number of rounds item by item without relief and calculate the
+// level of monkey business
+//
+// Items do not affect each other, so the whole game is a sum of games with
+// a single item. When worry levels are kept modulo Divisor, an item has a
+// finite number of states and sooner or later returns to a state it has
+// already been in. From that moment on the rounds repeat, and inspections
+// for the remaining rounds are extrapolated instead of being simulated.
+// Relief does not preserve remainders, games with relief are played
+// round by round with PlayN.
+//
+// Monkey business after so many rounds easily exceeds int64, so it is
+// returned with arbitrary precision.
+func (gang *MonkeyGang) PlayItems(rounds int) (*big.Int, error) {
+	gang.Relief = false
+	err := gang.CheckModular()
+	if err != nil {
+		return nil, fmt.Errorf("items can not be simulated independently: %w", err)
+	}
+	gang.Modular = true
+	owners := make(map[*Monkey]int, len(gang.Members))
+	for index, monkey := range gang.Members {
+		owners[monkey] = index
+	}
+	for _, item := range gang.Items {
+		if item.Big != nil {
+			return nil, fmt.Errorf("worry level too high for independent simulation: %v", item)
+		}
+		inspections, final, err := gang.playItem(itemState{item.Value, owners[item.Owner]}, rounds)
+		if err != nil {
+			return nil, err
+		}
+		for index, count := range inspections {
+			gang.Members[index].Business += count
+		}
+		item.Value = final.value
+		gang.Transfer(item, gang.Members[final.owner])
+	}
+	return gang.MonkeyBusinessBig(), nil
+}
+
+// Number of inspections by every monkey and the final state of an item
+func (gang *MonkeyGang) playItem(state itemState, rounds int) (inspections []int, final itemState, err error) {
+	seen := make(map[itemState]int) // round at which the state was first seen
+	var states []itemState          // state at the start of every round
+	history := [][]int{make([]int, len(gang.Members))}
+	for round := 0; round < rounds; round++ {
+		if first, ok := seen[state]; ok {
+			period := round - first
+			cycles, rest := (rounds-first)/period, (rounds-first)%period
+			inspections = make([]int, len(gang.Members))
+			for index := range inspections {
+				before := history[first][index]
+				perCycle := history[round][index] - before
+				inspections[index] = before + cycles*perCycle + history[first+rest][index] - before
+			}
+			return inspections, states[first+rest], nil
+		}
+		seen[state] = round
+		states = append(states, state)
+		counts := append([]int(nil), history[round]...)
+		state, err = gang.playItemRound(state, counts)
+		if err != nil {
+			return nil, state, fmt.Errorf("round %d: %w", round+1, err)
+		}
+		history = append(history, counts)
+	}
+	return history[rounds], state, nil
+}
+
+// Pass an item between monkeys until the end of the round
+//
+// Monkeys take turns in order, so an item thrown to a monkey further down
+// the list is inspected again during the same round.
+func (gang *MonkeyGang) playItemRound(state itemState, inspections []int) (itemState, error) {
+	owner := state.owner
+	item := &Item{Value: state.value}
+	for {
+		monkey := gang.Members[owner]
+		inspections[owner]++
+		divisible, err := gang.Inspect(monkey, item)
+		if err != nil {
+			return state, err
+		}
+		if item.Big != nil {
+			return state, fmt.Errorf("worry level is not reduced: %v", item)
+		}
+		dest := monkey.Destination[divisible]
+		if dest <= owner {
+			return itemState{item.Value, dest}, nil
+		}
+		owner = dest
+	}
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Arithmetic expression over the old worry level
+type Expression interface {
+	// Evaluate with machine integers, errOverflow means the result does
+	// not fit
+	Eval(old int64) (int64, error)
+	// Evaluate with arbitrary precision, result must not alias old
+	EvalBig(old *big.Int) (*big.Int, error)
+	String() string
+}
+
+var errOverflow = errors.New("integer overflow")
+
+// Reference to the old worry level
+type Old struct{}
+
+func (Old) Eval(old int64) (int64, error) {
+	return old, nil
+}
+
+func (Old) EvalBig(old *big.Int) (*big.Int, error) {
+	return new(big.Int).Set(old), nil
+}
+
+func (Old) String() string {
+	return "old"
+}
+
+type Const int64
+
+func (c Const) Eval(int64) (int64, error) {
+	return int64(c), nil
+}
+
+func (c Const) EvalBig(*big.Int) (*big.Int, error) {
+	return big.NewInt(int64(c)), nil
+}
+
+func (c Const) String() string {
+	return strconv.FormatInt(int64(c), 10)
+}
+
+type Negate struct {
+	Operand Expression
+}
+
+func (n Negate) Eval(old int64) (int64, error) {
+	value, err := n.Operand.Eval(old)
+	if err != nil {
+		return 0, err
+	}
+	if value == math.MinInt64 {
+		return 0, errOverflow
+	}
+	return -value, nil
+}
+
+func (n Negate) EvalBig(old *big.Int) (*big.Int, error) {
+	value, err := n.Operand.EvalBig(old)
+	if err != nil {
+		return nil, err
+	}
+	return value.Neg(value), nil
+}
+
+func (n Negate) String() string {
+	return "-" + n.Operand.String()
+}
+
+type Binary struct {
+	Operator byte // one of + - * / %
+	Left     Expression
+	Right    Expression
+}
+
+func (b Binary) Eval(old int64) (int64, error) {
+	left, err := b.Left.Eval(old)
+	if err != nil {
+		return 0, err
+	}
+	right, err := b.Right.Eval(old)
+	if err != nil {
+		return 0, err
+	}
+	var result int64
+	var ok bool
+	switch b.Operator {
+	case '+':
+		result = left + right
+		ok = (result > left) == (right > 0)
+	case '-':
+		result = left - right
+		ok = (result < left) == (right > 0)
+	case '*':
+		if left == 0 || right == 0 {
+			return 0, nil
+		}
+		result = left * right
+		ok = result/right == left && !(left == -1 && right == math.MinInt64) && !(right == -1 && left == math.MinInt64)
+	case '/', '%':
+		if right == 0 {
+			return 0, b.divisionByZero(old)
+		}
+		if right == -1 && left == math.MinInt64 {
+			return 0, errOverflow
+		}
+		if b.Operator == '/' {
+			return left / right, nil
+		}
+		return left % right, nil
+	default:
+		panic(fmt.Sprintf("unsupported operator: %c", b.Operator))
+	}
+	if !ok {
+		return 0, errOverflow
+	}
+	return result, nil
+}
+
+func (b Binary) EvalBig(old *big.Int) (*big.Int, error) {
+	left, err := b.Left.EvalBig(old)
+	if err != nil {
+		return nil, err
+	}
+	right, err := b.Right.EvalBig(old)
+	if err != nil {
+		return nil, err
+	}
+	switch b.Operator {
+	case '+':
+		return left.Add(left, right), nil
+	case '-':
+		return left.Sub(left, right), nil
+	case '*':
+		return left.Mul(left, right), nil
+	case '/', '%':
+		if right.Sign() == 0 {
+			return nil, b.divisionByZero(old)
+		}
+		if b.Operator == '/' {
+			return left.Quo(left, right), nil // truncated like int64 division
+		}
+		return left.Rem(left, right), nil
+	}
+	panic(fmt.Sprintf("unsupported operator: %c", b.Operator))
+}
+
+func (b Binary) divisionByZero(old interface{}) error {
+	return fmt.Errorf("division by zero: %v (old=%v)", b, old)
+}
+
+func (b Binary) String() string {
+	return fmt.Sprintf("(%v %c %v)", b.Left, b.Operator, b.Right)
+}
+
+// Check if expression is compatible with modular arithmetic
+//
+// Expressions built only from addition, subtraction and multiplication are
+// ring homomorphisms: evaluating them on remainders modulo any number gives
+// the remainder of the full result. Division and modulo do not have this
+// property, so worry levels can not be reduced for such expressions.
+func Homomorphic(expr Expression) error {
+	switch e := expr.(type) {
+	case Binary:
+		if e.Operator == '/' || e.Operator == '%' {
+			return fmt.Errorf("%v does not preserve remainders", e)
+		}
+		err := Homomorphic(e.Left)
+		if err != nil {
+			return err
+		}
+		return Homomorphic(e.Right)
+	case Negate:
+		return Homomorphic(e.Operand)
+	}
+	return nil
+}
+
+// Parse expression with integer constants, references to old value,
+// parentheses, unary minus and binary + - * / %
+//
+// Multiplicative operators bind tighter than additive ones, operators of
+// the same precedence are evaluated left to right.
+func ParseExpression(text string) (Expression, error) {
+	tokens, err := tokenize(text)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{tokens: tokens}
+	expr, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in %q", p.tokens[p.pos], text)
+	}
+	return expr, nil
+}
+
+func tokenize(text string) (tokens []string, err error) {
+	for i := 0; i < len(text); {
+		char := text[i]
+		switch {
+		case char == ' ' || char == '\t':
+			i++
+		case strings.IndexByte("+-*/%()", char) >= 0:
+			tokens = append(tokens, text[i:i+1])
+			i++
+		case char >= '0' && char <= '9' || char >= 'a' && char <= 'z':
+			start := i
+			for i < len(text) && (text[i] >= '0' && text[i] <= '9' || text[i] >= 'a' && text[i] <= 'z') {
+				i++
+			}
+			tokens = append(tokens, text[start:i])
+		default:
+			return nil, fmt.Errorf("unexpected character %q in %q", char, text)
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+	return tokens, nil
+}
+
+type exprParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *exprParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *exprParser) sum() (Expression, error) {
+	return p.binary("+-", p.product)
+}
+
+func (p *exprParser) product() (Expression, error) {
+	return p.binary("*/%", p.unary)
+}
+
+func (p *exprParser) binary(operators string, operand func() (Expression, error)) (Expression, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for len(p.peek()) == 1 && strings.Contains(operators, p.peek()) {
+		operator := p.peek()[0]
+		p.pos++
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		if c, ok := right.(Const); ok && c == 0 && (operator == '/' || operator == '%') {
+			return nil, fmt.Errorf("division by zero")
+		}
+		left = Binary{Operator: operator, Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) unary() (Expression, error) {
+	token := p.peek()
+	p.pos++
+	switch {
+	case token == "":
+		return nil, fmt.Errorf("unexpected end of expression")
+	case token == "-":
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return Negate{operand}, nil
+	case token == "(":
+		expr, err := p.sum()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return expr, nil
+	case token == "old":
+		return Old{}, nil
+	}
+	value, err := strconv.ParseInt(token, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected %q", token)
+	}
+	return Const(value), nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// State of every monkey after a round, indexed in the order of play
+type RoundStats struct {
+	Business []int // items inspected since the start of the game
+	Items    []int // items held
+}
+
+// Per round statistics of the game
+type History struct {
+	Rounds []RoundStats
+}
+
+func (h *History) Record(gang *MonkeyGang) {
+	stats := RoundStats{
+		Business: make([]int, len(gang.Members)),
+		Items:    make([]int, len(gang.Members)),
+	}
+	index := make(map[*Monkey]int, len(gang.Members))
+	for i, monkey := range gang.Members {
+		index[monkey] = i
+		stats.Business[i] = monkey.Business
+	}
+	for _, item := range gang.Items {
+		stats.Items[index[item.Owner]]++
+	}
+	h.Rounds = append(h.Rounds, stats)
+}
+
+// Export history with one row per monkey per round
+//
+// Besides total business each row contains the number of items inspected
+// during that round.
+func (h *History) WriteCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	err := out.Write([]string{"round", "monkey", "business", "inspected", "items"})
+	if err != nil {
+		return err
+	}
+	record := make([]string, 5)
+	for round, stats := range h.Rounds {
+		for monkey, business := range stats.Business {
+			inspected := business
+			if round > 0 {
+				inspected -= h.Rounds[round-1].Business[monkey]
+			}
+			record[0] = strconv.Itoa(round + 1)
+			record[1] = strconv.Itoa(monkey)
+			record[2] = strconv.Itoa(business)
+			record[3] = strconv.Itoa(inspected)
+			record[4] = strconv.Itoa(stats.Items[monkey])
+			err = out.Write(record)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
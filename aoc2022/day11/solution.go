@@ -1,50 +1,33 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
+	"math/big"
 	"sort"
 	"strconv"
 	"strings"
+
+	"aoc2022/puzzle"
 )
 
 type Item struct {
 	Value int64
+	Big   *big.Int // replaces Value when worry level does not fit into int64
 	Owner *Monkey
 }
 
-type Arithmetic int8
-
-const (
-	Add Arithmetic = 1 << iota
-	Multiply
-)
-
-type InspectOperation struct {
-	action Arithmetic
-	arg    int64
-	self   bool
-}
-
-func (op *InspectOperation) Apply(item *Item) {
-	var arg int64
-	arg = op.arg
-	if op.self {
-		arg = item.Value
-	}
-	switch op.action {
-	default:
-		panic(fmt.Sprintf("Apply() not implemented for action %b", op.action))
-	case Add:
-		item.Value += arg
-	case Multiply:
-		item.Value *= arg
+func (item *Item) String() string {
+	if item.Big != nil {
+		return item.Big.String()
 	}
+	return strconv.FormatInt(item.Value, 10)
 }
 
 type Monkey struct {
 	Business    int
-	Inspection  InspectOperation
+	Inspection  Expression
 	DivideBy    int64
 	Destination map[bool]int
 }
@@ -54,6 +37,10 @@ type MonkeyGang struct {
 	Items   []*Item
 	Relief  bool
 	Divisor int64
+	Modular bool // worry levels are kept modulo Divisor
+	MaxBits int  // limit for arbitrary precision worry levels, zero means no limit
+
+	History *History // optional
 }
 
 func (gang *MonkeyGang) Transfer(item *Item, owner *Monkey) {
@@ -64,7 +51,7 @@ func (gang *MonkeyGang) Obtain(item *Item) {
 	gang.Items = append(gang.Items, item)
 }
 
-func (gang *MonkeyGang) Play() {
+func (gang *MonkeyGang) Play() error {
 	var dest int
 	var item *Item
 	var monkey *Monkey
@@ -74,21 +61,74 @@ func (gang *MonkeyGang) Play() {
 				continue
 			}
 			monkey.Business++
-			monkey.Inspection.Apply(item)
-			if gang.Relief {
-				item.Value /= 3 // relief
+			divisible, err := gang.Inspect(monkey, item)
+			if err != nil {
+				return err
 			}
-			item.Value = item.Value % gang.Divisor
-			dest = monkey.Destination[item.Value%monkey.DivideBy == 0]
+			dest = monkey.Destination[divisible]
 			gang.Transfer(item, gang.Members[dest])
 		}
 	}
+	if gang.History != nil {
+		gang.History.Record(gang)
+	}
+	return nil
+}
+
+// Update worry level of the item and check if it is divisible by monkey's
+// test number
+//
+// Machine integers are used while the results fit, on overflow the item
+// switches to arbitrary precision and back when the value gets small
+// enough again.
+//
+// Relief does not preserve remainders, so worry levels are never reduced
+// modulo Divisor while it is on.
+func (gang *MonkeyGang) Inspect(monkey *Monkey, item *Item) (divisible bool, err error) {
+	modular := gang.Modular && !gang.Relief
+	if item.Big == nil {
+		value, err := monkey.Inspection.Eval(item.Value)
+		if err == nil {
+			if gang.Relief {
+				value /= 3 // relief
+			}
+			if modular {
+				value %= gang.Divisor
+			}
+			item.Value = value
+			return value%monkey.DivideBy == 0, nil
+		}
+		if err != errOverflow {
+			return false, err
+		}
+		item.Big = big.NewInt(item.Value)
+	}
+	value, err := monkey.Inspection.EvalBig(item.Big)
+	if err != nil {
+		return false, err
+	}
+	if gang.Relief {
+		value.Quo(value, big.NewInt(3))
+	}
+	if modular {
+		value.Rem(value, big.NewInt(gang.Divisor))
+	}
+	if gang.MaxBits > 0 && value.BitLen() > gang.MaxBits {
+		return false, fmt.Errorf("worry level exceeds %d bits after %v", gang.MaxBits, monkey.Inspection)
+	}
+	divisible = new(big.Int).Rem(value, big.NewInt(monkey.DivideBy)).Sign() == 0
+	if value.IsInt64() {
+		item.Value, item.Big = value.Int64(), nil
+	} else {
+		item.Big = value
+	}
+	return divisible, nil
 }
 
 const (
 	PrefixMonkey    = "Monkey "
 	PrefixItems     = "Starting items: "
-	PrefixOperation = "Operation: new = old "
+	PrefixOperation = "Operation: new = "
 	PrefixTest      = "Test: divisible by "
 	PrefixTestTrue  = "If true: throw to monkey "
 	PrefixTestFalse = "If false: throw to monkey "
@@ -99,6 +139,11 @@ func (gang *MonkeyGang) Parse(line string) (err error) {
 	var value int
 	var chunk string
 	var monkey *Monkey
+	if len(gang.Members) > 0 {
+		monkey = gang.Last()
+	} else if line != "" && !strings.HasPrefix(line, PrefixMonkey) {
+		return fmt.Errorf("monkey description must start with %q", PrefixMonkey)
+	}
 	switch {
 
 	default:
@@ -127,63 +172,46 @@ func (gang *MonkeyGang) Parse(line string) (err error) {
 			}
 			item := &Item{Value: int64(value)}
 			gang.Obtain(item)
-			gang.Transfer(item, gang.Last())
+			gang.Transfer(item, monkey)
 		}
 
 	case strings.HasPrefix(line, PrefixOperation):
 		line = line[len(PrefixOperation):]
-		monkey = gang.Last()
-		var chunks []string
-		chunks = strings.Split(line, " ")
-		if len(chunks) != 2 {
-			return fmt.Errorf("unexpected inspection formula: %s (%d words)", line, len(chunks))
-		}
-		ops := map[string]Arithmetic{
-			"+": Add,
-			"*": Multiply,
-		}
-		action, found := ops[chunks[0]]
-		if !found {
-			return fmt.Errorf("unsupported arithmetic operation: %s", chunks[0])
-		}
-		monkey.Inspection = InspectOperation{
-			action: action,
-		}
-		if chunks[1] == "old" {
-			monkey.Inspection.self = true
-		} else {
-			value, err = strconv.Atoi(chunks[1])
-			if err != nil {
-				return fmt.Errorf("could not parse arithmetic argument: %w", err)
-			}
-			monkey.Inspection.arg = int64(value)
+		monkey.Inspection, err = ParseExpression(line)
+		if err != nil {
+			return fmt.Errorf("unexpected inspection formula: %w", err)
 		}
-
 	case strings.HasPrefix(line, PrefixTest):
 		line = line[len(PrefixTest):]
-		monkey = gang.Last()
 		value, err = strconv.Atoi(line)
 		if err != nil {
 			return fmt.Errorf("cannot parse number: %s", line)
 		}
+		if value <= 0 {
+			return fmt.Errorf("divisibility test requires a positive number, got %d", value)
+		}
 		monkey.DivideBy = int64(value)
 
 	case strings.HasPrefix(line, PrefixTestTrue):
 		line = line[len(PrefixTestTrue):]
-		monkey = gang.Last()
 		value, err = strconv.Atoi(line)
 		if err != nil {
 			return fmt.Errorf("cannot parse number: %s", line)
 		}
+		if value < 0 {
+			return fmt.Errorf("invalid monkey number: %d", value)
+		}
 		monkey.Destination[true] = value
 
 	case strings.HasPrefix(line, PrefixTestFalse):
 		line = line[len(PrefixTestFalse):]
-		monkey = gang.Last()
 		value, err = strconv.Atoi(line)
 		if err != nil {
 			return fmt.Errorf("cannot parse number: %s", line)
 		}
+		if value < 0 {
+			return fmt.Errorf("invalid monkey number: %d", value)
+		}
 		monkey.Destination[false] = value
 	}
 	return nil
@@ -197,7 +225,7 @@ func (gang *MonkeyGang) Print() {
 			if item.Owner != monkey {
 				continue
 			}
-			fmt.Printf("%d ", item.Value)
+			fmt.Printf("%v ", item)
 		}
 		fmt.Println()
 	}
@@ -213,13 +241,82 @@ func (gang *MonkeyGang) Last() *Monkey {
 	return gang.Members[len(gang.Members)-1]
 }
 
-func ReadMonkeyGang(filename string) *MonkeyGang {
+// Deep copy of the gang, copied items are owned by the copied monkeys
+func (gang *MonkeyGang) Copy() *MonkeyGang {
+	clone := &MonkeyGang{
+		Members: make([]*Monkey, len(gang.Members)),
+		Items:   make([]*Item, len(gang.Items)),
+		Relief:  gang.Relief,
+		Divisor: gang.Divisor,
+		Modular: gang.Modular,
+		MaxBits: gang.MaxBits,
+	}
+	owners := make(map[*Monkey]*Monkey, len(gang.Members))
+	for index, monkey := range gang.Members {
+		m := *monkey
+		m.Destination = make(map[bool]int, len(monkey.Destination))
+		for key, value := range monkey.Destination {
+			m.Destination[key] = value
+		}
+		clone.Members[index] = &m
+		owners[monkey] = &m
+	}
+	for index, item := range gang.Items {
+		clone.Items[index] = &Item{Value: item.Value, Owner: owners[item.Owner]}
+		if item.Big != nil {
+			clone.Items[index].Big = new(big.Int).Set(item.Big)
+		}
+	}
+	return clone
+}
+
+func ReadMonkeyGang(filename string) (*MonkeyGang, error) {
 	gang := &MonkeyGang{}
-	var err error
-	for line := range ReadLines(filename) {
+	lines, err := puzzle.ReadLines(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+	for line := range lines.C {
 		err = gang.Parse(line)
 		if err != nil {
-			log.Fatalf("%q: %v", line, err)
+			return nil, fmt.Errorf("%q: %w", line, err)
+		}
+	}
+	err = lines.Close()
+	if err != nil {
+		return nil, err
+	}
+	err = gang.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return gang, nil
+}
+
+// Check that every monkey is described completely and calculate Divisor
+//
+// Destinations may refer to monkeys described later, so they are checked
+// only after the whole input has been parsed.
+func (gang *MonkeyGang) Validate() error {
+	if len(gang.Members) == 0 {
+		return fmt.Errorf("no monkeys found")
+	}
+	for index, monkey := range gang.Members {
+		if monkey.Inspection == nil {
+			return fmt.Errorf("monkey %d: missing operation", index)
+		}
+		if monkey.DivideBy == 0 {
+			return fmt.Errorf("monkey %d: missing test", index)
+		}
+		for _, outcome := range []bool{true, false} {
+			dest, ok := monkey.Destination[outcome]
+			if !ok {
+				return fmt.Errorf("monkey %d: missing destination if %v", index, outcome)
+			}
+			if dest >= len(gang.Members) {
+				return fmt.Errorf("monkey %d: throws to monkey %d, but there are only %d monkeys", index, dest, len(gang.Members))
+			}
 		}
 	}
 	multipliers := make(map[int64]bool)
@@ -232,30 +329,103 @@ func ReadMonkeyGang(filename string) *MonkeyGang {
 	for key, _ := range multipliers {
 		gang.Divisor *= key
 	}
-	return gang
+	return nil
 }
 
-func (gang *MonkeyGang) PlayN(rounds int, relief bool, debug bool) int {
+// Check if worry levels can be kept modulo Divisor without affecting
+// where the items are thrown
+func (gang *MonkeyGang) CheckModular() error {
+	for index, monkey := range gang.Members {
+		err := Homomorphic(monkey.Inspection)
+		if err != nil {
+			return fmt.Errorf("monkey %d: %w", index, err)
+		}
+	}
+	return nil
+}
+
+// Play given number of rounds and calculate the level of monkey business
+//
+// Without relief worry levels grow quickly, so they are kept modulo Divisor
+// when all inspections preserve remainders. Otherwise exact values are
+// tracked with arbitrary precision, which may get slow.
+func (gang *MonkeyGang) PlayN(rounds int, relief bool, debug bool) (int, error) {
 	gang.Relief = relief
+	err := gang.CheckModular()
+	if err != nil && debug {
+		log.Printf("worry levels can not be reduced: %v", err)
+	}
+	gang.Modular = err == nil && !relief
 	for i := 0; i < rounds; i++ {
-		gang.Play()
+		err = gang.Play()
+		if err != nil {
+			return 0, fmt.Errorf("round %d: %w", i+1, err)
+		}
 	}
 	if debug {
 		gang.Print()
 	}
-	monkeys := gang.Members
-	sort.Slice(monkeys, func(i, j int) bool {
-		return monkeys[i].Business > monkeys[j].Business
-	})
-	return monkeys[0].Business * monkeys[1].Business
+	return gang.MonkeyBusiness(), nil
+}
+
+// Product of business of two most active monkeys
+//
+// The product overflows after about 10^9 rounds, use MonkeyBusinessBig for
+// longer games.
+func (gang *MonkeyGang) MonkeyBusiness() int {
+	top := gang.busiest()
+	return top[0] * top[1]
+}
+
+func (gang *MonkeyGang) MonkeyBusinessBig() *big.Int {
+	top := gang.busiest()
+	return new(big.Int).Mul(big.NewInt(int64(top[0])), big.NewInt(int64(top[1])))
+}
+
+func (gang *MonkeyGang) busiest() []int {
+	business := make([]int, len(gang.Members))
+	for i, monkey := range gang.Members {
+		business[i] = monkey.Business
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(business)))
+	return business[:2]
+}
+
+var (
+	historyFile = flag.String("history", "", "save per round statistics to `file` in CSV format (use with -part)")
+	maxBits     = flag.Int("maxbits", 1<<20, "stop when worry level grows beyond that many `bits` (0 means no limit)")
+	rounds      = flag.Int("rounds", 10000, "number of `rounds` to play in part 2")
+)
+
+// Simulate items independently when possible, relief and history require
+// playing round by round
+func play(gang *MonkeyGang, rounds int, relief bool) string {
+	gang.MaxBits = *maxBits
+	if *historyFile != "" {
+		gang.History = &History{}
+	}
+	var err error
+	if !relief && gang.History == nil && gang.CheckModular() == nil {
+		_, err = gang.PlayItems(rounds)
+	} else {
+		_, err = gang.PlayN(rounds, relief, false)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if gang.History != nil {
+		err = puzzle.WriteFile(*historyFile, gang.History.WriteCSV)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	return gang.MonkeyBusinessBig().String()
 }
 
-func part1(filename string) string {
-	gang := ReadMonkeyGang(filename)
-	return strconv.Itoa(gang.PlayN(20, true, false))
+func part1(gang *MonkeyGang) string {
+	return play(gang, 20, true)
 }
 
-func part2(filename string) string {
-	gang := ReadMonkeyGang(filename)
-	return strconv.Itoa(gang.PlayN(10000, false, false))
+func part2(gang *MonkeyGang) string {
+	return play(gang, *rounds, false)
 }
@@ -1,24 +1,41 @@
 package main
 
 import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
 	"testing"
 
 	"strings"
+
+	"aoc2022/puzzle/puzzletest"
 )
 
 const sample = "sample.txt"
 
 func TestSample(t *testing.T) {
 	tests := []struct {
-		worker func(string) string
+		worker func(*MonkeyGang) string
 		input  string
 		result string
 	}{
 		{worker: part1, input: sample, result: "10605"},
 		{worker: part2, input: sample, result: "2713310158"},
 	}
+	parsed := make(map[string]*MonkeyGang)
 	for i, test := range tests {
-		got := strings.TrimSpace(test.worker(test.input))
+		input, ok := parsed[test.input]
+		if !ok {
+			var err error
+			input, err = ReadMonkeyGang(test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			parsed[test.input] = input
+		}
+		got := strings.TrimSpace(test.worker(input.Copy()))
 		expected := strings.TrimSpace(test.result)
 		if got != expected {
 			t.Errorf("sample: part %d expected %q, got %q", i%2+1, expected, got)
@@ -39,14 +56,368 @@ func TestSample(t *testing.T) {
 	}
 }
 
+func TestExpression(t *testing.T) {
+	tests := []struct {
+		text    string
+		old     int64
+		result  int64
+		modular bool
+	}{
+		{"old * 19", 3, 57, true},
+		{"old * old", 7, 49, true},
+		{"old + 6", 1, 7, true},
+		{"(old + 1) * (old - 2)", 5, 18, true},
+		{"old * old - old * 2 + 1", 4, 9, true},
+		{"-old + 10", 4, 6, true},
+		{"2 * (3 + old) % 5", 1, 3, false},
+		{"old / 2 + old % 3", 11, 7, false},
+		{"100 - 10 - 1", 0, 89, true},
+	}
+	for _, test := range tests {
+		expr, err := ParseExpression(test.text)
+		if err != nil {
+			t.Errorf("%q: %v", test.text, err)
+			continue
+		}
+		if got, err := expr.Eval(test.old); got != test.result || err != nil {
+			t.Errorf("%q (%v), old=%d: want %d, got %d (%v)", test.text, expr, test.old, test.result, got, err)
+		}
+		if got, err := expr.EvalBig(big.NewInt(test.old)); err != nil || !got.IsInt64() || got.Int64() != test.result {
+			t.Errorf("%q (%v), old=%d: want %d, got %v with arbitrary precision (%v)", test.text, expr, test.old, test.result, got, err)
+		}
+		if err = Homomorphic(expr); (err == nil) != test.modular {
+			t.Errorf("%q: homomorphic want %v, got %v", test.text, test.modular, err)
+		}
+	}
+	for _, text := range []string{"", "old +", "(old", "old)", "old ^ 2", "new * 2", "old / 0", "2 old"} {
+		if _, err := ParseExpression(text); err == nil {
+			t.Errorf("invalid expression accepted: %q", text)
+		}
+	}
+}
+
+// Read gang description the same way puzzle input is read
+func parseGang(t *testing.T, lines ...string) *MonkeyGang {
+	t.Helper()
+	gang, err := ReadMonkeyGang(puzzletest.WriteInput(t, strings.Join(lines, "\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gang
+}
+
+func TestParseErrors(t *testing.T) {
+	monkey := func(number, test, ifTrue, ifFalse string) string {
+		return strings.Join([]string{
+			"Monkey " + number + ":",
+			"  Starting items: 1",
+			"  Operation: new = old + 1",
+			"  Test: divisible by " + test,
+			"    If true: throw to monkey " + ifTrue,
+			"    If false: throw to monkey " + ifFalse,
+		}, "\n")
+	}
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{monkey("0", "0", "1", "1"), "divisibility test requires a positive number, got 0"},
+		{monkey("0", "-3", "1", "1"), "divisibility test requires a positive number, got -3"},
+		{monkey("0", "3", "-1", "0"), "invalid monkey number: -1"},
+		{monkey("0", "3", "0", "1"), "monkey 0: throws to monkey 1, but there are only 1 monkeys"},
+		{monkey("0", "3", "1", "1") + "\n" + monkey("1", "2", "0", "2"), "monkey 1: throws to monkey 2, but there are only 2 monkeys"},
+		{"  Starting items: 1", "monkey description must start with"},
+		{"Monkey 0:\n  Operation: new = old", "monkey 0: missing test"},
+		{"", "no monkeys found"},
+	}
+	for _, test := range tests {
+		_, err := ReadMonkeyGang(puzzletest.WriteInput(t, test.input))
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%q: want error %q, got %v", test.input, test.err, err)
+		}
+	}
+}
+
+func TestNonModular(t *testing.T) {
+	gang := parseGang(t,
+		"Monkey 0:",
+		"  Starting items: 79, 98",
+		"  Operation: new = old / 2 + 7",
+		"  Test: divisible by 23",
+		"    If true: throw to monkey 1",
+		"    If false: throw to monkey 1",
+		"Monkey 1:",
+		"  Starting items: 54",
+		"  Operation: new = (old + 6) * 2",
+		"  Test: divisible by 19",
+		"    If true: throw to monkey 0",
+		"    If false: throw to monkey 0",
+	)
+	if gang.Divisor != 23*19 {
+		t.Errorf("want divisor %d, got %d", 23*19, gang.Divisor)
+	}
+	_, err := gang.Copy().PlayN(20, true, false)
+	if err != nil {
+		t.Errorf("relief should keep worry levels low, got %v", err)
+	}
+	err = gang.CheckModular()
+	want := "monkey 0: (old / 2) does not preserve remainders"
+	if err == nil || err.Error() != want {
+		t.Errorf("want error %q, got %v", want, err)
+	}
+	_, err = gang.Copy().PlayN(20, false, false)
+	if err != nil {
+		t.Errorf("exact worry levels should be used without relief, got %v", err)
+	}
+}
+
+// Floor division by 3 does not preserve remainders, worry levels must not be
+// reduced modulo Divisor when relief is on
+func TestReliefNotModular(t *testing.T) {
+	gang := parseGang(t,
+		"Monkey 0:",
+		"  Starting items: 12, 7, 30",
+		"  Operation: new = old * 5",
+		"  Test: divisible by 2",
+		"    If true: throw to monkey 1",
+		"    If false: throw to monkey 1",
+		"Monkey 1:",
+		"  Starting items: 25",
+		"  Operation: new = old * 3 + 1",
+		"  Test: divisible by 3",
+		"    If true: throw to monkey 0",
+		"    If false: throw to monkey 0",
+	)
+	small, exact := gang.Copy(), gang.Copy()
+	exact.Divisor = 1 << 40 // never reached
+	want, err := exact.PlayN(20, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := small.PlayN(20, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("want monkey business %d, got %d", want, got)
+	}
+	for i, item := range small.Items {
+		if item.Value != exact.Items[i].Value {
+			t.Errorf("item %d: want worry level %d, got %d", i, exact.Items[i].Value, item.Value)
+		}
+	}
+	if got := part1(gang.Copy()); got != strconv.Itoa(want) {
+		t.Errorf("part 1: want monkey business %d, got %s", want, got)
+	}
+}
+
+func TestDivisionByZero(t *testing.T) {
+	gang := parseGang(t,
+		"Monkey 0:",
+		"  Starting items: 9, 5",
+		"  Operation: new = 100 / (old - 5)",
+		"  Test: divisible by 2",
+		"    If true: throw to monkey 1",
+		"    If false: throw to monkey 1",
+		"Monkey 1:",
+		"  Operation: new = old",
+		"  Test: divisible by 3",
+		"    If true: throw to monkey 0",
+		"    If false: throw to monkey 0",
+	)
+	_, err := gang.PlayN(1, false, false)
+	if err == nil || !strings.Contains(err.Error(), "division by zero") {
+		t.Errorf("want division by zero, got %v", err)
+	}
+}
+
+func TestOverflow(t *testing.T) {
+	for _, text := range []string{"old * old", "old + old", "-old - old", "old * -old"} {
+		expr, err := ParseExpression(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, old := range []int64{math.MaxInt64, math.MinInt64 + 1, 1 << 32, -1 << 32, 1 << 62} {
+			exact, err := expr.EvalBig(big.NewInt(old))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := expr.Eval(old)
+			ok := err == nil
+			if ok != exact.IsInt64() || ok && got != exact.Int64() || !ok && err != errOverflow {
+				t.Errorf("%s, old=%d: want %v, got %d (ok=%v)", text, old, exact, got, ok)
+			}
+		}
+	}
+
+	gang := parseGang(t,
+		"Monkey 0:",
+		"  Starting items: 3, 4",
+		"  Operation: new = old * old / 2",
+		"  Test: divisible by 2",
+		"    If true: throw to monkey 1",
+		"    If false: throw to monkey 1",
+		"Monkey 1:",
+		"  Operation: new = old",
+		"  Test: divisible by 3",
+		"    If true: throw to monkey 0",
+		"    If false: throw to monkey 0",
+	)
+	gang.History = &History{}
+	business, err := gang.PlayN(7, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if business != 14*14 {
+		t.Errorf("want monkey business %d, got %d", 14*14, business)
+	}
+	exact := big.NewInt(4)
+	for i := 0; i < 7; i++ {
+		exact.Mul(exact, exact)
+		exact.Quo(exact, big.NewInt(2))
+	}
+	if gang.Items[1].Big == nil || gang.Items[1].Big.Cmp(exact) != 0 {
+		t.Errorf("want exact worry level %v, got %v", exact, gang.Items[1])
+	}
+	if len(gang.History.Rounds) != 7 {
+		t.Fatalf("want 7 rounds in history, got %d", len(gang.History.Rounds))
+	}
+	if last := gang.History.Rounds[6]; last.Business[1] != 14 || last.Items[0] != 2 || last.Items[1] != 0 {
+		t.Errorf("unexpected stats of the last round: %+v", last)
+	}
+	var out strings.Builder
+	err = gang.History.WriteCSV(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(rows) != 15 || rows[0] != "round,monkey,business,inspected,items" || rows[14] != "7,1,14,2,0" {
+		t.Errorf("unexpected history in CSV:\n%s", out.String())
+	}
+
+	gang.MaxBits = 100
+	_, err = gang.PlayN(1, false, false)
+	if err == nil || !strings.Contains(err.Error(), "exceeds 100 bits") {
+		t.Errorf("want error for huge worry level, got %v", err)
+	}
+}
+
+func TestPlayItems(t *testing.T) {
+	gang, err := ReadMonkeyGang(sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rounds := range []int{20, 1000, 10000} {
+		byRound, byItem := gang.Copy(), gang.Copy()
+		want, err := byRound.PlayN(rounds, false, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := byItem.PlayItems(rounds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.IsInt64() || got.Int64() != int64(want) {
+			t.Errorf("%d rounds: want monkey business %d, got %v", rounds, want, got)
+		}
+		for i, item := range byItem.Items {
+			other := byRound.Items[i]
+			if item.Value != other.Value || item.Owner.DivideBy != other.Owner.DivideBy {
+				t.Errorf("%d rounds, item %d: want %d at monkey dividing by %d, got %d at %d", rounds, i, other.Value, other.Owner.DivideBy, item.Value, item.Owner.DivideBy)
+			}
+		}
+	}
+}
+
+// Detect the period of the whole game by playing round by round, then
+// extrapolate inspections to a huge number of rounds that ends at the
+// same phase of the cycle
+func TestPlayItemsHuge(t *testing.T) {
+	gang, err := ReadMonkeyGang(sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot := func(gang *MonkeyGang) string {
+		var b strings.Builder
+		for _, item := range gang.Items {
+			fmt.Fprintf(&b, "%d@%d,", item.Value, item.Owner.DivideBy)
+		}
+		return b.String()
+	}
+	business := func(gang *MonkeyGang) []int64 {
+		counts := make([]int64, len(gang.Members))
+		for i, monkey := range gang.Members {
+			counts[i] = int64(monkey.Business)
+		}
+		return counts
+	}
+	byRound := gang.Copy()
+	seen := make(map[string]int)
+	var history [][]int64
+	var start, period int
+	for round := 0; ; round++ {
+		state := snapshot(byRound)
+		if first, ok := seen[state]; ok {
+			start, period = first, round-first
+			break
+		}
+		seen[state] = round
+		history = append(history, business(byRound))
+		_, err = byRound.PlayN(1, false, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	before, after := history[start], business(byRound)
+
+	cycles := 1_000_000_000_000 / period
+	rounds := start + cycles*period
+	want := make([]int64, len(before))
+	for i := range want {
+		want[i] = before[i] + int64(cycles)*(after[i]-before[i])
+	}
+	sort.Slice(want, func(i, j int) bool { return want[i] > want[j] })
+	wantBusiness := new(big.Int).Mul(big.NewInt(want[0]), big.NewInt(want[1]))
+
+	byItem := gang.Copy()
+	got, err := byItem.PlayItems(rounds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Cmp(wantBusiness) != 0 {
+		t.Errorf("%d rounds: want monkey business %v, got %v", rounds, wantBusiness, got)
+	}
+	if got.IsInt64() {
+		t.Errorf("%d rounds: monkey business %v must not fit into int64", rounds, got)
+	}
+	atStart := gang.Copy()
+	_, err = atStart.PlayN(start, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot(byItem) != snapshot(atStart) {
+		t.Errorf("%d rounds: items must be where they were after %d rounds", rounds, start)
+	}
+}
+
 func BenchmarkPart1(b *testing.B) {
+	input, err := ReadMonkeyGang(sample)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		part1(sample)
+		part1(input.Copy())
 	}
 }
 
 func BenchmarkPart2(b *testing.B) {
+	input, err := ReadMonkeyGang(sample)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		part2(sample)
+		part2(input.Copy())
 	}
 }
@@ -2,8 +2,9 @@ package main
 
 import (
 	"fmt"
-	"log"
 	"strconv"
+
+	"aoc2022/puzzle"
 )
 
 const unreachable = int(^uint(0) >> 1) // max int
@@ -155,12 +156,28 @@ func NewMap() *Map {
 	return m
 }
 
+// Deep copy of the area, distance cache is not copied
+func (m *Map) Copy() *Map {
+	clone := NewMap()
+	for point, height := range m.Height {
+		clone.Height[point] = height
+	}
+	clone.Start = m.Start
+	clone.Finish = m.Finish
+	return clone
+}
+
 func ParseArea(filename string) (*Map, error) {
 	var x, y int
 	var line string
 	var char rune
 	var area = NewMap()
-	for line = range ReadLines(filename) {
+	lines, err := puzzle.ReadLines(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+	for line = range lines.C {
 		x = 0
 		for _, char = range line {
 			switch char {
@@ -179,29 +196,32 @@ func ParseArea(filename string) (*Map, error) {
 		}
 		y++
 	}
+	err = lines.Close()
+	if err != nil {
+		return nil, err
+	}
 	return area, nil
 }
 
-func part1(filename string) string {
-	area, err := ParseArea(filename)
-	if err != nil {
-		log.Fatal(err)
-	}
+func part1(area *Map) string {
 	trail := area.Route(area.Start, area.Finish)
 	return strconv.Itoa(trail)
 }
 
-func part2(filename string) string {
-	area, err := ParseArea(filename)
-	if err != nil {
-		log.Fatal(err)
-	}
-	var min, trail int
+func part2(area *Map) string {
+	var starts []Point
 	for start, height := range area.Height {
 		if height != 'a' {
 			continue
 		}
-		trail = area.Route(start, area.Finish)
+		starts = append(starts, start)
+	}
+	trails := puzzle.ParallelMap(starts, func(start Point) int {
+		worker := *area // Route() overwrites distance cache, height map is shared read-only
+		return worker.Route(start, area.Finish)
+	})
+	var min int
+	for _, trail := range trails {
 		if trail < min || min == 0 {
 			min = trail
 		}
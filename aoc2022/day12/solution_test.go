@@ -4,21 +4,33 @@ import (
 	"testing"
 
 	"strings"
+
+	"aoc2022/puzzle/puzzletest"
 )
 
 const sample = "sample.txt"
 
 func TestSample(t *testing.T) {
 	tests := []struct {
-		worker func(string) string
+		worker func(*Map) string
 		input  string
 		result string
 	}{
 		{worker: part1, input: sample, result: "31"},
 		{worker: part2, input: sample, result: "29"},
 	}
+	parsed := make(map[string]*Map)
 	for i, test := range tests {
-		got := strings.TrimSpace(test.worker(test.input))
+		input, ok := parsed[test.input]
+		if !ok {
+			var err error
+			input, err = ParseArea(test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			parsed[test.input] = input
+		}
+		got := strings.TrimSpace(test.worker(input.Copy()))
 		expected := strings.TrimSpace(test.result)
 		if got != expected {
 			t.Errorf("sample: part %d expected %q, got %q", i%2+1, expected, got)
@@ -39,14 +51,35 @@ func TestSample(t *testing.T) {
 	}
 }
 
+// Route is called from ParallelMap workers, run with -race
+func TestConcurrent(t *testing.T) {
+	input, err := ParseArea(sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	puzzletest.Concurrent(t, 4, "29", func() string {
+		return part2(input.Copy())
+	})
+}
+
 func BenchmarkPart1(b *testing.B) {
+	input, err := ParseArea(sample)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		part1(sample)
+		part1(input.Copy())
 	}
 }
 
 func BenchmarkPart2(b *testing.B) {
+	input, err := ParseArea(sample)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		part2(sample)
+		part2(input.Copy())
 	}
 }
@@ -5,6 +5,8 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+
+	"aoc2022/puzzle"
 )
 
 const (
@@ -105,6 +107,20 @@ func (list *NestedList) Append(value int) {
 	list.Items = append(list.Items, &NestedList{Value: value})
 }
 
+func (list *NestedList) Copy() *NestedList {
+	clone := &NestedList{
+		Value:  list.Value,
+		Nested: list.Nested,
+		Parent: list.Parent,
+	}
+	for _, item := range list.Items {
+		item = item.Copy()
+		item.Parent = clone
+		clone.Items = append(clone.Items, item)
+	}
+	return clone
+}
+
 func (list *NestedList) String() string {
 	if list == nil {
 		return "<nil>"
@@ -182,34 +198,15 @@ func (cursor *Cursor) Parse(line string) (err error) {
 	return nil
 }
 
-func part1(filename string) string {
-	var err error
-	var pair [2]*NestedList
-	var index, pairIndex, result, compare int
-	for line := range ReadLines(filename) {
-		if len(line) == 0 {
-			continue
-		}
-		if index > 1 || pairIndex == 0 {
-			index = 0
-			pairIndex++
-		}
-		pair[index] = &NestedList{}
-		err = pair[index].Parse(line)
-		if err != nil {
-			return fmt.Sprintf("parsing failed: %v", err)
-		}
-		index++
-		if index > 1 {
-			compare = pair[0].Compare(pair[1])
-			//fmt.Printf("#%03d: ", pairIndex)
-			if compare == Less {
-				result += pairIndex
-				//fmt.Printf("  correct ")
-			} else {
-				//fmt.Printf("incorrect ")
-			}
-			//fmt.Printf(": %s\n                  %s (%d)\n", pair[0], pair[1], compare)
+func part1(packets Packets) string {
+	if len(packets)%2 != 0 {
+		return fmt.Sprintf("odd number of packets: %d", len(packets))
+	}
+	var result, compare int
+	for index := 0; index < len(packets); index += 2 {
+		compare = packets[index].Compare(packets[index+1])
+		if compare == Less {
+			result += index/2 + 1
 		}
 	}
 	return strconv.Itoa(result)
@@ -229,6 +226,39 @@ func (p Packets) Swap(i, j int) {
 	p[i], p[j] = p[j], p[i]
 }
 
+func ReadPackets(filename string) (packets Packets, err error) {
+	var p *NestedList
+	lines, err := puzzle.ReadLines(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+	for line := range lines.C {
+		if len(line) == 0 {
+			continue
+		}
+		p = &NestedList{}
+		err = p.Parse(line)
+		if err != nil {
+			return nil, fmt.Errorf("parsing failed: %w", err)
+		}
+		packets = append(packets, p)
+	}
+	err = lines.Close()
+	if err != nil {
+		return nil, err
+	}
+	return packets, nil
+}
+
+func (p Packets) Copy() Packets {
+	clone := make(Packets, len(p))
+	for index, packet := range p {
+		clone[index] = packet.Copy()
+	}
+	return clone
+}
+
 // Packets slice must be sorted beforehand!
 func (p Packets) Find(needle ...*NestedList) (result int) {
 	targets := Packets(needle)
@@ -248,7 +278,7 @@ func (p Packets) Find(needle ...*NestedList) (result int) {
 	return result
 }
 
-func part2(filename string) string {
+func part2(input Packets) string {
 	var Needle [2]*NestedList
 	for index, line := range []string{
 		"[[2]]",
@@ -258,17 +288,7 @@ func part2(filename string) string {
 		Needle[index].Parse(line)
 	}
 
-	packets := Packets(Needle[:])
-	var p *NestedList
-	for line := range ReadLines(filename) {
-		if len(line) == 0 {
-			continue
-		}
-		p = &NestedList{}
-		p.Parse(line)
-		packets = append(packets, p)
-		//fmt.Printf("Got %d packets, latest: %s\n", packets.Len(), p)
-	}
+	packets := append(Packets(Needle[:]), input...)
 	sort.Sort(packets)
 
 	var result int
@@ -5,13 +5,15 @@ import (
 
 	"strconv"
 	"strings"
+
+	"aoc2022/puzzle"
 )
 
 const sample = "sample.txt"
 
 func TestSample(t *testing.T) {
 	tests := []struct {
-		worker func(string) string
+		worker func(Packets) string
 		input  string
 		result string
 	}{
@@ -19,8 +21,18 @@ func TestSample(t *testing.T) {
 		{worker: part2, input: sample, result: "140"},
 		{worker: part1, input: "sample2.txt", result: strconv.Itoa(0 + 3)},
 	}
+	parsed := make(map[string]Packets)
 	for i, test := range tests {
-		got := strings.TrimSpace(test.worker(test.input))
+		input, ok := parsed[test.input]
+		if !ok {
+			var err error
+			input, err = ReadPackets(test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			parsed[test.input] = input
+		}
+		got := strings.TrimSpace(test.worker(input.Copy()))
 		expected := strings.TrimSpace(test.result)
 		if got != expected {
 			t.Errorf("sample: part %d expected %q, got %q", i%2+1, expected, got)
@@ -47,7 +59,11 @@ func TestParsing(t *testing.T) {
 		"input.txt",
 	}
 	for _, filename := range files {
-		for line := range ReadLines(filename) {
+		lines, err := puzzle.ReadLines(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for line := range lines.C {
 			if len(line) == 0 {
 				continue
 			}
@@ -57,11 +73,18 @@ func TestParsing(t *testing.T) {
 				t.Errorf("parsing error (%v): %s -> %s", err, line, list.String())
 			}
 		}
+		if err = lines.Close(); err != nil {
+			t.Fatal(err)
+		}
 	}
 }
 
 func TestGuessing(t *testing.T) {
-	value, err := strconv.Atoi(part1("input.txt"))
+	packets, err := ReadPackets("input.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, err := strconv.Atoi(part1(packets))
 	if err != nil {
 		t.Errorf("number parsing error: %v", err)
 	}
@@ -71,13 +94,23 @@ func TestGuessing(t *testing.T) {
 }
 
 func BenchmarkPart1(b *testing.B) {
+	input, err := ReadPackets(sample)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		part1(sample)
+		part1(input.Copy())
 	}
 }
 
 func BenchmarkPart2(b *testing.B) {
+	input, err := ReadPackets(sample)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		part2(sample)
+		part2(input.Copy())
 	}
 }
@@ -2,9 +2,10 @@ package main
 
 import (
 	"fmt"
-	"log"
 	"strconv"
 	"strings"
+
+	"aoc2022/puzzle"
 )
 
 type Point struct {
@@ -62,6 +63,22 @@ type Map struct {
 	floor  int
 }
 
+func (m *Map) Copy() *Map {
+	clone := &Map{
+		tiles:  make(map[Point]Tile, len(m.tiles)),
+		recent: m.recent,
+		floor:  m.floor,
+	}
+	for place, tile := range m.tiles {
+		clone.tiles[place] = tile
+	}
+	if m.area != nil {
+		area := *m.area
+		clone.area = &area
+	}
+	return clone
+}
+
 func (m *Map) Draw() string {
 	return m.DrawRectangle(m.area)
 }
@@ -186,7 +203,12 @@ func (m *Map) FromFile(filename string) (err error) {
 	var i int
 	var start, end, cursor Point
 	var direction Direction
-	for line = range ReadLines(filename) {
+	lines, err := puzzle.ReadLines(filename)
+	if err != nil {
+		return err
+	}
+	defer lines.Close()
+	for line = range lines.C {
 		points = strings.Split(line, " -> ")
 		for i = 0; i < len(points)-1; i++ {
 			err = start.Parse(points[i])
@@ -214,6 +236,10 @@ func (m *Map) FromFile(filename string) (err error) {
 			}
 		}
 	}
+	err = lines.Close()
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -241,19 +267,11 @@ func ReadCave(filename string) (*Map, error) {
 	return cave, nil
 }
 
-func part1(filename string) string {
-	cave, err := ReadCave(filename)
-	if err != nil {
-		log.Fatalf("%v", err)
-	}
+func part1(cave *Map) string {
 	return strconv.Itoa(cave.PourSand(Point{500, 0}))
 }
 
-func part2(filename string) string {
-	cave, err := ReadCave(filename)
-	if err != nil {
-		log.Fatalf("%v", err)
-	}
+func part2(cave *Map) string {
 	cave.AddFloor(2)
 	return strconv.Itoa(cave.PourSand(Point{500, 0}))
 }
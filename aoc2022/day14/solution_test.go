@@ -10,15 +10,25 @@ const sample = "sample.txt"
 
 func TestSample(t *testing.T) {
 	tests := []struct {
-		worker func(string) string
+		worker func(*Map) string
 		input  string
 		result string
 	}{
 		{worker: part1, input: sample, result: "24"},
 		{worker: part2, input: sample, result: "93"},
 	}
+	parsed := make(map[string]*Map)
 	for i, test := range tests {
-		got := strings.TrimSpace(test.worker(test.input))
+		input, ok := parsed[test.input]
+		if !ok {
+			var err error
+			input, err = ReadCave(test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			parsed[test.input] = input
+		}
+		got := strings.TrimSpace(test.worker(input.Copy()))
 		expected := strings.TrimSpace(test.result)
 		if got != expected {
 			t.Errorf("sample: part %d expected %q, got %q", i%2+1, expected, got)
@@ -40,13 +50,23 @@ func TestSample(t *testing.T) {
 }
 
 func BenchmarkPart1(b *testing.B) {
+	input, err := ReadCave(sample)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		part1(sample)
+		part1(input.Copy())
 	}
 }
 
 func BenchmarkPart2(b *testing.B) {
+	input, err := ReadCave(sample)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		part2(sample)
+		part2(input.Copy())
 	}
 }
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+)
+
+// Check every single point of the search area
+//
+// Unlike Search this does not assume that the beacon is located at the
+// perimeter of some sensor's coverage, but it is only feasible for small
+// areas. All uncovered points are returned, the puzzle promises there is
+// only one of them.
+func (m *Map) ReferenceSearch(min, max int) (found []Point, err error) {
+	var cursor Point
+	for cursor.Y = min; cursor.Y <= max; cursor.Y++ {
+		for cursor.X = min; cursor.X <= max; cursor.X++ {
+			if !m.occupied[cursor] && !m.Covered(cursor) {
+				found = append(found, cursor)
+			}
+		}
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("beacon not found in area from (%d,%d) to (%d,%d)", min, min, max, max)
+	}
+	return found, nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"testing"
+
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"aoc2022/puzzle/puzzletest"
+)
+
+type sensorInput struct {
+	lines []string
+	size  int
+}
+
+func (input sensorInput) String() string {
+	return fmt.Sprintf("area from (0,0) to (%d,%d)\n%s", input.size, input.size, strings.Join(input.lines, "\n"))
+}
+
+func (input sensorInput) Map() *Map {
+	cave := &Map{}
+	for _, line := range input.lines {
+		err := cave.Parse(line)
+		if err != nil {
+			panic(err)
+		}
+	}
+	return cave
+}
+
+// Place sensors until the only uncovered point in the area is a hidden beacon
+func generateSensors(rng *rand.Rand) sensorInput {
+	input := sensorInput{size: 5 + rng.Intn(26)}
+	hidden := Point{rng.Intn(input.size + 1), rng.Intn(input.size + 1)}
+	cave := &Map{}
+	for {
+		found, err := cave.ReferenceSearch(0, input.size)
+		if err != nil {
+			panic(err)
+		}
+		var uncovered []Point
+		for _, p := range found {
+			if p != hidden {
+				uncovered = append(uncovered, p)
+			}
+		}
+		if len(uncovered) == 0 {
+			return input
+		}
+		target := uncovered[rng.Intn(len(uncovered))]
+		sensor := &Sensor{Location: target}
+		radius := target.Distance(hidden) - 1
+		if radius == 0 { // cover the target with a beacon placed next to it
+			sensor.Location = Point{2*target.X - hidden.X, 2*target.Y - hidden.Y}
+			sensor.Beacon = target
+		} else {
+			var perimeter []Point
+			iter := target.Perimeter(radius)
+			for iter.Next() {
+				perimeter = append(perimeter, iter.Value)
+			}
+			sensor.Beacon = perimeter[rng.Intn(len(perimeter))]
+		}
+		line := fmt.Sprintf(
+			"Sensor at x=%d, y=%d: closest beacon is at x=%d, y=%d",
+			sensor.Location.X, sensor.Location.Y,
+			sensor.Beacon.X, sensor.Beacon.Y,
+		)
+		input.lines = append(input.lines, line)
+		err = cave.Parse(line)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Smaller inputs derived from the given one: each is missing one sensor
+func shrinkSensors(input sensorInput) (smaller []sensorInput) {
+	for i := range input.lines {
+		lines := make([]string, 0, len(input.lines)-1)
+		lines = append(lines, input.lines[:i]...)
+		lines = append(lines, input.lines[i+1:]...)
+		smaller = append(smaller, sensorInput{lines, input.size})
+	}
+	return smaller
+}
+
+func fastSearch(input sensorInput) (Point, error) {
+	return puzzletest.Recover(func() (Point, error) {
+		return input.Map().Search(0, input.size)
+	})
+}
+
+// Inputs with several possible beacon locations are not valid puzzles,
+// those are never reported as mismatches
+func mismatchSensors(input sensorInput) bool {
+	want, err := input.Map().ReferenceSearch(0, input.size)
+	if err != nil || len(want) != 1 {
+		return false
+	}
+	got, err := fastSearch(input)
+	return err != nil || got != want[0]
+}
+
+func TestDifferential(t *testing.T) {
+	for seed := int64(0); seed < 100; seed++ {
+		input := generateSensors(rand.New(rand.NewSource(seed)))
+		want, err := input.Map().ReferenceSearch(0, input.size)
+		if err != nil || len(want) != 1 {
+			t.Fatalf("seed=%d: generated invalid input (%v, %v):\n%s", seed, want, err, input)
+		}
+		if !mismatchSensors(input) {
+			continue
+		}
+		input = puzzletest.Shrink(input, shrinkSensors, mismatchSensors)
+		want, _ = input.Map().ReferenceSearch(0, input.size)
+		got, err := fastSearch(input)
+		t.Errorf("seed=%d: solvers disagree: reference %v, fast %v (error: %v)\n%s", seed, want[0], got, err, input)
+	}
+}
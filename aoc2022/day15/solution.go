@@ -6,6 +6,9 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync/atomic"
+
+	"aoc2022/puzzle"
 )
 
 type Point struct {
@@ -134,28 +137,34 @@ func (m *Map) CountCovered(row int) (count int) {
 //
 // I could not figure that out on my own, used Reddit for help (cheating)
 func (m *Map) Search(min, max int) (Point, error) {
-	var sensor *Sensor
-	var found bool
-	var iter *PointIterator
-	for _, sensor = range m.sensors {
-		iter = sensor.Location.Perimeter(sensor.Radius() + 1)
-		for !found && iter.Next() {
+	type candidate struct {
+		location Point
+		found    bool
+	}
+	var found int32 // other workers stop early once the beacon is found
+	results := puzzle.ParallelMap(m.sensors, func(sensor *Sensor) (result candidate) {
+		iter := sensor.Location.Perimeter(sensor.Radius() + 1)
+		for iter.Next() {
+			if atomic.LoadInt32(&found) != 0 {
+				return result
+			}
 			if iter.Value.X < min || iter.Value.X > max || iter.Value.Y < min || iter.Value.Y > max {
 				continue
 			}
 			if !m.occupied[iter.Value] && !m.Covered(iter.Value) {
-				found = true // we assume that only one beacon location is possible
-				fmt.Printf("Found beacon: %v\n", iter.Value)
+				atomic.StoreInt32(&found, 1)
+				return candidate{iter.Value, true}
 			}
 		}
-		if found {
-			break
+		return result
+	})
+	for _, result := range results { // we assume that only one beacon location is possible
+		if result.found {
+			fmt.Printf("Found beacon: %v\n", result.location)
+			return result.location, nil
 		}
 	}
-	if !found {
-		return Point{}, fmt.Errorf("beacon not found in area from (%d,%d) to (%d,%d)", min, min, max, max)
-	}
-	return iter.Value, nil
+	return Point{}, fmt.Errorf("beacon not found in area from (%d,%d) to (%d,%d)", min, min, max, max)
 }
 
 func Min(a, b int) int {
@@ -208,6 +217,22 @@ func (m *Map) AddSensor(s *Sensor) {
 	m.bounds.Extend(s.Beacon)
 }
 
+func (m *Map) Copy() *Map {
+	clone := &Map{
+		sensors:  make([]*Sensor, len(m.sensors)),
+		occupied: make(map[Point]bool, len(m.occupied)),
+		bounds:   m.bounds,
+	}
+	for index, sensor := range m.sensors {
+		s := *sensor
+		clone.sensors[index] = &s
+	}
+	for point, value := range m.occupied {
+		clone.occupied[point] = value
+	}
+	return clone
+}
+
 func (m *Map) Draw() string {
 	tiles := make(map[Point]rune)
 	var focus *Sensor
@@ -239,47 +264,65 @@ func (m *Map) Draw() string {
 	return b.String()
 }
 
-func part1(filename string) string {
-	row := 2000000
+// Sensor readings and puzzle parameters, which differ for sample input
+type Report struct {
+	Cave      *Map
+	Row       int // part 1 checks a single row
+	SearchMax int // part 2 searches from (0,0) to (SearchMax,SearchMax)
+}
+
+func ReadReport(filename string) (*Report, error) {
+	report := &Report{
+		Cave:      &Map{},
+		Row:       2000000,
+		SearchMax: 4000000,
+	}
 	if strings.HasSuffix(filename, "sample.txt") {
-		row = 10
+		report.Row = 10
+		report.SearchMax = 20
 	}
-	fmt.Printf("Checking row %d\n", row)
-
-	var err error
-	cave := &Map{}
-	for line := range ReadLines(filename) {
-		err = cave.Parse(line)
+	lines, err := puzzle.ReadLines(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+	for line := range lines.C {
+		err = report.Cave.Parse(line)
 		if err != nil {
-			log.Fatalf("could not parse line: %q: %v", line, err)
+			return nil, fmt.Errorf("could not parse line: %q: %w", line, err)
 		}
 	}
+	err = lines.Close()
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (r *Report) Copy() *Report {
+	clone := *r
+	clone.Cave = r.Cave.Copy()
+	return &clone
+}
+
+func part1(report *Report) string {
+	row := report.Row
+	fmt.Printf("Checking row %d\n", row)
+
+	cave := report.Cave
 	if row == 10 {
 		fmt.Println(cave.Draw())
 	}
 	return strconv.Itoa(cave.CountCovered(row))
 }
 
-func part2(filename string) string {
+func part2(report *Report) string {
 	var min, max int
 	min = 0
-	max = 4000000
-	if strings.HasSuffix(filename, "sample.txt") {
-		max = 20
-	}
+	max = report.SearchMax
 	fmt.Printf("Checking from (%d,%d) to (%d,%d)\n", min, min, max, max)
 
-	var err error
-	cave := &Map{}
-	for line := range ReadLines(filename) {
-		err = cave.Parse(line)
-		if err != nil {
-			log.Fatalf("could not parse line: %q: %v", line, err)
-		}
-	}
-
-	var beacon Point
-	beacon, err = cave.Search(min, max)
+	beacon, err := report.Cave.Search(min, max)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -2,6 +2,11 @@ package main
 
 import (
 	"testing"
+
+	"math/rand"
+	"sort"
+
+	"aoc2022/puzzle/puzzletest"
 )
 
 func TestDistances(t *testing.T) {
@@ -51,3 +56,70 @@ func TestDistances(t *testing.T) {
 
 // With my input the winning path yields a reward = 1724:
 //	 [AI KB QK CJ KS CU YE]
+
+// Best reward for each subset of working valves opened by a single actor
+//
+// Result is monotone: a subset also counts the best reward of its own
+// subsets, so that two actors may simply split working valves between them.
+func bestBySubset(g *Graph, minutes int) (best []int, full int) {
+	var working []*Valve
+	for _, valve := range g.nodes {
+		if valve.Rate > 0 {
+			working = append(working, valve)
+		}
+	}
+	sort.Slice(working, func(i, j int) bool { return working[i].Name < working[j].Name })
+	best = make([]int, 1<<len(working))
+	var visit func(cursor *Valve, limit, opened, reward int)
+	visit = func(cursor *Valve, limit, opened, reward int) {
+		if reward > best[opened] {
+			best[opened] = reward
+		}
+		for i, valve := range working {
+			left := limit - g.Distance(cursor, valve) - 1
+			if opened&(1<<i) != 0 || left <= 0 {
+				continue
+			}
+			visit(valve, left, opened|1<<i, reward+valve.Rate*left)
+		}
+	}
+	start, _ := g.Get("AA")
+	visit(start, minutes, 0, 0)
+	for i := range working {
+		for subset := range best {
+			if subset&(1<<i) != 0 && best[subset^1<<i] > best[subset] {
+				best[subset] = best[subset^1<<i]
+			}
+		}
+	}
+	return best, len(best) - 1
+}
+
+// One of the players may need to stand aside and let the other one open
+// remaining valves, otherwise the search misses the best plan
+func TestStandAside(t *testing.T) {
+	puzzletest.Seeds(t, 50, func(t *testing.T, seed int64, rng *rand.Rand) {
+		valves := 7 + rng.Intn(8)
+		input, err := GenerateTunnels(rng, valves, 1+rng.Intn(6))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tunnels, err := ReadGraph(puzzletest.WriteInput(t, input))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		best, full := bestBySubset(tunnels.Copy(), 26)
+		var together int
+		for subset := range best {
+			together = Max(together, best[subset]+best[full^subset])
+		}
+		if got := Play(tunnels.Copy(), 26, 2); got != together {
+			t.Errorf("two players: want %d, got %d", together, got)
+		}
+		best, full = bestBySubset(tunnels.Copy(), 30)
+		if got := Play(tunnels.Copy(), 30, 1); got != best[full] {
+			t.Errorf("single player: want %d, got %d", best[full], got)
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// Generate random puzzle input with the given number of valves
+func Generate(rng *rand.Rand, size int) (string, error) {
+	return GenerateTunnels(rng, size, size/4+1)
+}
+
+// Generate a connected network of valves with a few working ones
+//
+// Starting valve AA is always present and always has zero flow rate, just
+// like in the real puzzle inputs.
+func GenerateTunnels(rng *rand.Rand, valves, working int) (string, error) {
+	const alphabet = 'Z' - 'A' + 1
+	if valves < 2 || valves > alphabet*alphabet {
+		return "", fmt.Errorf("number of valves out of range: %d (want 2 to %d)", valves, alphabet*alphabet)
+	}
+	if working < 0 || working >= valves {
+		return "", fmt.Errorf("invalid number of working valves: %d (out of %d)", working, valves)
+	}
+
+	names := make([]string, 0, alphabet*alphabet)
+	for first := 'A'; first <= 'Z'; first++ {
+		for second := 'A'; second <= 'Z'; second++ {
+			if first == 'A' && second == 'A' {
+				continue
+			}
+			names = append(names, string([]rune{first, second}))
+		}
+	}
+	rng.Shuffle(len(names), func(i, j int) { names[i], names[j] = names[j], names[i] })
+	names = append([]string{"AA"}, names[:valves-1]...)
+
+	tunnels := make([]map[int]bool, valves)
+	for i := range tunnels {
+		tunnels[i] = make(map[int]bool)
+	}
+	connect := func(a, b int) {
+		tunnels[a][b] = true
+		tunnels[b][a] = true
+	}
+	for i := 1; i < valves; i++ {
+		connect(i, rng.Intn(i)) // spanning tree keeps the network connected
+	}
+	for i := 0; i < valves/2; i++ {
+		a, b := rng.Intn(valves), rng.Intn(valves)
+		if a != b {
+			connect(a, b)
+		}
+	}
+
+	rates := make([]int, valves)
+	for _, index := range rng.Perm(valves - 1)[:working] {
+		rates[index+1] = rng.Intn(25) + 1
+	}
+
+	var b strings.Builder
+	for i, name := range names {
+		var neighbors []string
+		for j := 0; j < valves; j++ {
+			if tunnels[i][j] {
+				neighbors = append(neighbors, names[j])
+			}
+		}
+		rng.Shuffle(len(neighbors), func(i, j int) { neighbors[i], neighbors[j] = neighbors[j], neighbors[i] })
+		if len(neighbors) == 1 {
+			fmt.Fprintf(&b, "Valve %s has flow rate=%d; tunnel leads to valve %s\n", name, rates[i], neighbors[0])
+		} else {
+			fmt.Fprintf(&b, "Valve %s has flow rate=%d; tunnels lead to valves %s\n", name, rates[i], strings.Join(neighbors, ", "))
+		}
+	}
+	return b.String(), nil
+}
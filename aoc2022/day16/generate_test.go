@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+
+	"fmt"
+	"math/rand"
+
+	"aoc2022/puzzle/puzzletest"
+)
+
+func TestGeneratedTunnels(t *testing.T) {
+	puzzletest.Seeds(t, 20, func(t *testing.T, seed int64, rng *rand.Rand) {
+		valves := 5 + int(seed)%10
+		input, err := GenerateTunnels(rng, valves, valves/3+1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tunnels, err := ReadGraph(puzzletest.WriteInput(t, input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tunnels.nodes) != valves {
+			t.Fatalf("want %d valves, got %d", valves, len(tunnels.nodes))
+		}
+		for _, valve := range tunnels.nodes {
+			for _, neighbor := range valve.Neighbors {
+				if tunnels.Distance(valve, neighbor) != 1 || tunnels.Distance(neighbor, valve) != 1 {
+					t.Errorf("tunnel between %v and %v is not bidirectional", valve, neighbor)
+				}
+			}
+		}
+
+		alone := Play(tunnels.Copy(), 26, 1)
+		if longer := Play(tunnels.Copy(), 30, 1); longer < alone {
+			t.Errorf("more time yields lower reward: %d < %d", longer, alone)
+		}
+		if together := Play(tunnels.Copy(), 26, 2); together < alone {
+			t.Errorf("elephant's help yields lower reward: %d < %d", together, alone)
+		}
+	})
+}
+
+func TestGenerateSize(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, size := range []int{-1, 0, 1, 677} {
+		if _, err := Generate(rng, size); err == nil {
+			t.Errorf("no error for invalid size %d", size)
+		}
+	}
+	for _, size := range []int{2, 676} {
+		if _, err := Generate(rng, size); err != nil {
+			t.Errorf("size %d: %v", size, err)
+		}
+	}
+}
+
+func BenchmarkGenerated(b *testing.B) {
+	for _, size := range [][2]int{{10, 4}, {30, 6}, {60, 8}} {
+		valves, working := size[0], size[1]
+		rng := rand.New(rand.NewSource(1))
+		input, err := GenerateTunnels(rng, valves, working)
+		if err != nil {
+			b.Fatal(err)
+		}
+		tunnels, err := ReadGraph(puzzletest.WriteInput(b, input))
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, players := range []int{1, 2} {
+			b.Run(fmt.Sprintf("valves=%d/working=%d/players=%d", valves, working, players), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					Play(tunnels.Copy(), 30-4*(players-1), players)
+				}
+			})
+		}
+	}
+}
@@ -2,11 +2,12 @@ package main
 
 import (
 	"fmt"
-	"log"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+
+	"aoc2022/puzzle"
 )
 
 type Valves []string
@@ -216,6 +217,17 @@ func (g *Graph) multiSearch(search SearchState, actors SearchActors) {
 		possibilities = append(possibilities, actors[i].NextMoves(&search, g))
 	}
 
+	// with several actors it may be better for one of them to stand aside
+	// and leave remaining valves to others
+	if len(actors) > 1 {
+		for i = 0; i < len(actors); i++ {
+			if len(possibilities[i]) == 0 {
+				continue
+			}
+			possibilities[i] = append(possibilities[i], SearchMove{Dest: actors[i].Cursor, Cost: actors[i].Limit})
+		}
+	}
+
 	// drop actors without any moves left
 	var keep int
 	for i = 0; i < len(actors); i++ {
@@ -335,13 +347,52 @@ func (g *Graph) GetOrCreate(name string) *Valve {
 	return valve
 }
 
+// Deep copy of the graph, including cached distances and best known reward
+func (g *Graph) Copy() *Graph {
+	clone := &Graph{MaxReward: g.MaxReward}
+	for name, valve := range g.nodes {
+		clone.GetOrCreate(name).Rate = valve.Rate
+	}
+	for name, valve := range g.nodes {
+		copied := clone.nodes[name]
+		for _, neighbor := range valve.Neighbors {
+			copied.Neighbors = append(copied.Neighbors, clone.nodes[neighbor.Name])
+		}
+	}
+	if g.distance != nil {
+		clone.distance = make(map[[2]string]int, len(g.distance))
+		for key, value := range g.distance {
+			clone.distance[key] = value
+		}
+	}
+	return clone
+}
+
+func ReadGraph(filename string) (*Graph, error) {
+	tunnels := &Graph{}
+	err := tunnels.ParseFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return tunnels, nil
+}
+
 func (g *Graph) ParseFile(filename string) (err error) {
-	for line := range ReadLines(filename) {
+	lines, err := puzzle.ReadLines(filename)
+	if err != nil {
+		return err
+	}
+	defer lines.Close()
+	for line := range lines.C {
 		err = g.Parse(line)
 		if err != nil {
 			return err
 		}
 	}
+	err = lines.Close()
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -368,20 +419,14 @@ func (g *Graph) Parse(line string) (err error) {
 	return nil
 }
 
-func Play(filename string, moves int, players int) int {
-	var err error
-	tunnels := &Graph{}
-	err = tunnels.ParseFile(filename)
-	if err != nil {
-		log.Fatal(err)
-	}
+func Play(tunnels *Graph, moves int, players int) int {
 	return tunnels.Search("AA", moves, players)
 }
 
-func part1(filename string) string {
-	return strconv.Itoa(Play(filename, 30, 1))
+func part1(tunnels *Graph) string {
+	return strconv.Itoa(Play(tunnels, 30, 1))
 }
 
-func part2(filename string) string {
-	return strconv.Itoa(Play(filename, 26, 2))
+func part2(tunnels *Graph) string {
+	return strconv.Itoa(Play(tunnels, 26, 2))
 }
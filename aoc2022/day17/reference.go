@@ -0,0 +1,18 @@
+package main
+
+// Simulate every single rock without loop extrapolation
+//
+// This is way too slow for part 2, but it does not rely on any assumptions
+// about repeating patterns, which makes it useful for validating DropN
+// results on small inputs.
+func ReferenceHeight(jets []Direction, rocks int64) int64 {
+	chamber := Chamber{
+		width:          ChamberWidth,
+		pushDirections: jets,
+	}
+	var i int64
+	for i = 0; i < rocks; i++ {
+		chamber.Next(-1)
+	}
+	return chamber.Height()
+}
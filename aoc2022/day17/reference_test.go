@@ -0,0 +1,92 @@
+package main
+
+import (
+	"testing"
+
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"aoc2022/puzzle/puzzletest"
+)
+
+type jetInput struct {
+	jets  []Direction
+	rocks int64
+}
+
+func (input jetInput) String() string {
+	var b strings.Builder
+	for _, jet := range input.jets {
+		if jet == Left {
+			b.WriteRune('<')
+		} else {
+			b.WriteRune('>')
+		}
+	}
+	return fmt.Sprintf("%s (%d rocks)", b.String(), input.rocks)
+}
+
+func generateJets(rng *rand.Rand) jetInput {
+	input := jetInput{
+		jets:  make([]Direction, 1+rng.Intn(40)),
+		rocks: 1 + rng.Int63n(2000),
+	}
+	for i := range input.jets {
+		input.jets[i] = Right
+		if rng.Intn(2) == 0 {
+			input.jets[i] = Left
+		}
+	}
+	return input
+}
+
+// Smaller inputs derived from the given one: fewer rocks or fewer jets
+func shrinkJets(input jetInput) (smaller []jetInput) {
+	if input.rocks > 1 {
+		smaller = append(smaller, jetInput{input.jets, input.rocks / 2})
+		smaller = append(smaller, jetInput{input.jets, input.rocks - 1})
+	}
+	for i := 0; i < len(input.jets) && len(input.jets) > 1; i++ {
+		jets := make([]Direction, 0, len(input.jets)-1)
+		jets = append(jets, input.jets[:i]...)
+		jets = append(jets, input.jets[i+1:]...)
+		smaller = append(smaller, jetInput{jets, input.rocks})
+	}
+	return smaller
+}
+
+func fastHeight(input jetInput) (int64, error) {
+	return puzzletest.Recover(func() (int64, error) {
+		chamber := Chamber{
+			width:          ChamberWidth,
+			pushDirections: input.jets,
+		}
+		chamber.DropN(input.rocks)
+		return chamber.Height(), nil
+	})
+}
+
+func mismatchJets(input jetInput) bool {
+	got, err := fastHeight(input)
+	return err != nil || got != ReferenceHeight(input.jets, input.rocks)
+}
+
+func TestDifferential(t *testing.T) {
+	for seed := int64(0); seed < 100; seed++ {
+		input := generateJets(rand.New(rand.NewSource(seed)))
+		if !mismatchJets(input) {
+			continue
+		}
+		input = puzzletest.Shrink(input, shrinkJets, mismatchJets)
+		got, err := fastHeight(input)
+		t.Errorf(
+			"seed=%d: solvers disagree on %s: reference %d, fast %d (error: %v)",
+			seed,
+			input,
+			ReferenceHeight(input.jets, input.rocks),
+			got,
+			err,
+		)
+	}
+}
@@ -3,6 +3,8 @@ package main
 import (
 	"fmt"
 	"strings"
+
+	"aoc2022/puzzle"
 )
 
 const ChamberWidth = 7
@@ -218,10 +220,47 @@ func (chamber *Chamber) Render() string {
 	return builder.String()
 }
 
-func (chamber *Chamber) ReadJetPattern(filename string) {
+func (chamber *Chamber) Copy() *Chamber {
+	clone := *chamber
+	if chamber.rocks != nil {
+		clone.rocks = make(map[Point]bool, len(chamber.rocks))
+		for point, value := range chamber.rocks {
+			clone.rocks[point] = value
+		}
+	}
+	if chamber.seen != nil {
+		clone.seen = make(map[ChamberSnapshot]ChamberStatus, len(chamber.seen))
+		for snapshot, status := range chamber.seen {
+			clone.seen[snapshot] = status
+		}
+	}
+	clone.spawnFrom = make([]Shape, len(chamber.spawnFrom))
+	for index, shape := range chamber.spawnFrom {
+		shape.rocks = append([]Direction(nil), shape.rocks...)
+		clone.spawnFrom[index] = shape
+	}
+	clone.pushDirections = append([]Direction(nil), chamber.pushDirections...)
+	return &clone
+}
+
+func ReadChamber(filename string) (*Chamber, error) {
+	chamber := &Chamber{width: ChamberWidth}
+	err := chamber.ReadJetPattern(filename)
+	if err != nil {
+		return nil, err
+	}
+	return chamber, nil
+}
+
+func (chamber *Chamber) ReadJetPattern(filename string) error {
 	var char rune
 	var direction Direction
-	for char = range ReadChars(filename) {
+	chars, err := puzzle.ReadChars(filename)
+	if err != nil {
+		return err
+	}
+	defer chars.Close()
+	for char = range chars.C {
 		switch char {
 		case '<':
 			direction = Left
@@ -232,23 +271,26 @@ func (chamber *Chamber) ReadJetPattern(filename string) {
 		case '\r':
 			continue
 		default:
-			panic(fmt.Sprintf("unsupported direction: %c (%v)", char, char))
+			return fmt.Errorf("unsupported direction: %c (%v)", char, char)
 		}
 		chamber.pushDirections = append(chamber.pushDirections, direction)
 	}
+	err = chars.Close()
+	if err != nil {
+		return err
+	}
+	return nil
 }
 
-func Play(filename string, rounds int64) string {
-	chamber := Chamber{width: ChamberWidth}
-	chamber.ReadJetPattern(filename)
+func Play(chamber *Chamber, rounds int64) string {
 	chamber.DropN(rounds)
 	return fmt.Sprintf("%d", chamber.Height())
 }
 
-func part1(filename string) string {
-	return Play(filename, 2022)
+func part1(chamber *Chamber) string {
+	return Play(chamber, 2022)
 }
 
-func part2(filename string) string {
-	return Play(filename, 1000000000000)
+func part2(chamber *Chamber) string {
+	return Play(chamber, 1000000000000)
 }
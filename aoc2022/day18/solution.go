@@ -2,6 +2,8 @@ package main
 
 import (
 	"fmt"
+
+	"aoc2022/puzzle"
 )
 
 type Shape struct {
@@ -9,16 +11,46 @@ type Shape struct {
 	top    Point
 }
 
-func (s *Shape) FromFile(filename string) {
+func (s *Shape) FromFile(filename string) error {
 	var line string
 	var point Point
-	for line = range ReadLines(filename) {
-		point.Parse(line)
+	lines, err := puzzle.ReadLines(filename)
+	if err != nil {
+		return err
+	}
+	defer lines.Close()
+	for line = range lines.C {
+		err = point.Parse(line)
+		if err != nil {
+			return err
+		}
 		s.Add(point)
 		if point.Z > s.top.Z {
 			s.top = point
 		}
 	}
+	err = lines.Close()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func ReadShape(filename string) (*Shape, error) {
+	shape := &Shape{}
+	err := shape.FromFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return shape, nil
+}
+
+func (s *Shape) Copy() *Shape {
+	clone := &Shape{top: s.top}
+	for p := range s.filled {
+		clone.Add(p)
+	}
+	return clone
 }
 
 func (s *Shape) Add(p Point) {
@@ -88,14 +120,10 @@ func (s *Shape) Adjacent(p Point) bool {
 	return false
 }
 
-func part1(filename string) string {
-	shape := &Shape{}
-	shape.FromFile(filename)
+func part1(shape *Shape) string {
 	return fmt.Sprintf("%d", shape.SurfaceArea())
 }
 
-func part2(filename string) string {
-	shape := &Shape{}
-	shape.FromFile(filename)
+func part2(shape *Shape) string {
 	return fmt.Sprintf("%d", shape.ProperArea())
 }
@@ -10,7 +10,7 @@ type Blueprint struct {
 	maxGeodeStock ResourceValue
 }
 
-func (b *Blueprint) Parse(line string) {
+func (b *Blueprint) Parse(line string) error {
 	_, err := fmt.Sscanf(
 		line,
 		"Blueprint %d: Each ore robot costs %d ore. Each clay robot costs %d ore. Each obsidian robot costs %d ore and %d clay. Each geode robot costs %d ore and %d obsidian.",
@@ -23,8 +23,9 @@ func (b *Blueprint) Parse(line string) {
 		&b.Cost[Geode][Obsidian],
 	)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("invalid blueprint: %w", err)
 	}
+	return nil
 }
 
 func (b *Blueprint) Optimize(moves int) {
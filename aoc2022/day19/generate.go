@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// Generate random puzzle input with the given number of blueprints
+func Generate(rng *rand.Rand, size int) (string, error) {
+	return GenerateBlueprints(rng, size)
+}
+
+// Generate blueprints with robot costs in the same ranges as real inputs
+func GenerateBlueprints(rng *rand.Rand, count int) (string, error) {
+	if count < 1 {
+		return "", fmt.Errorf("number of blueprints must be positive: %d", count)
+	}
+	between := func(min, max int) int {
+		return min + rng.Intn(max-min+1)
+	}
+	var b strings.Builder
+	for id := 1; id <= count; id++ {
+		fmt.Fprintf(
+			&b,
+			"Blueprint %d: Each ore robot costs %d ore. Each clay robot costs %d ore. Each obsidian robot costs %d ore and %d clay. Each geode robot costs %d ore and %d obsidian.\n",
+			id,
+			between(2, 4),
+			between(2, 4),
+			between(2, 4),
+			between(5, 20),
+			between(2, 4),
+			between(5, 20),
+		)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+
+	"fmt"
+	"math/rand"
+
+	"aoc2022/puzzle/puzzletest"
+)
+
+func generated(tb testing.TB, rng *rand.Rand, count int) Blueprints {
+	tb.Helper()
+	input, err := GenerateBlueprints(rng, count)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	blueprints, err := ReadBlueprints(puzzletest.WriteInput(tb, input))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return blueprints
+}
+
+func TestGeneratedBlueprints(t *testing.T) {
+	const count = 5
+	blueprints := generated(t, rand.New(rand.NewSource(1)), count)
+	if len(blueprints) != count {
+		t.Fatalf("want %d blueprints, got %d", count, len(blueprints))
+	}
+	for index, b := range blueprints {
+		if b.ID != index+1 {
+			t.Errorf("unexpected blueprint ID: want %d, got %d", index+1, b.ID)
+		}
+		var previous int
+		for _, minutes := range []int{12, 16, 20, 24} {
+			b.Optimize(minutes)
+			if b.MaxGeodes() < previous {
+				t.Errorf("blueprint %d: more time yields less geodes: %d minutes -> %d geodes, previously %d", b.ID, minutes, b.MaxGeodes(), previous)
+			}
+			if b.Quality() != b.ID*b.MaxGeodes() {
+				t.Errorf("blueprint %d: unexpected quality level %d for %d geodes", b.ID, b.Quality(), b.MaxGeodes())
+			}
+			previous = b.MaxGeodes()
+		}
+	}
+	if _, err := Generate(rand.New(rand.NewSource(1)), 0); err == nil {
+		t.Errorf("no error for empty list of blueprints")
+	}
+}
+
+func BenchmarkGenerated(b *testing.B) {
+	for _, count := range []int{1, 5, 30} {
+		blueprints := generated(b, rand.New(rand.NewSource(1)), count)
+		b.Run(fmt.Sprintf("blueprints=%d", count), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				part1(blueprints.Copy())
+			}
+		})
+	}
+}
@@ -2,48 +2,64 @@ package main
 
 import (
 	"fmt"
-	"log"
+
+	"aoc2022/puzzle"
 )
 
-func part1(filename string) string {
+type Blueprints []*Blueprint
+
+func ReadBlueprints(filename string) (blueprints Blueprints, err error) {
 	var iter LineIterator
-	err := iter.Open(filename)
+	err = iter.Open(filename)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer iter.Close()
 
-	var blueprint Blueprint
-	var total int
 	for iter.Next() {
-		blueprint.Parse(iter.Value())
-		blueprint.Optimize(24)
-		total += blueprint.Quality()
-		//fmt.Printf("%3d: %3d\n", blueprint.ID, blueprint.Quality())
+		blueprint := &Blueprint{}
+		err = blueprint.Parse(iter.Value())
+		if err != nil {
+			return nil, err
+		}
+		blueprints = append(blueprints, blueprint)
 	}
-	return fmt.Sprint(total)
+	return blueprints, nil
 }
 
-func part2(filename string) string {
-	var iter LineIterator
-	err := iter.Open(filename)
-	if err != nil {
-		log.Fatal(err)
+func (blueprints Blueprints) Copy() Blueprints {
+	clone := make(Blueprints, len(blueprints))
+	for index, b := range blueprints {
+		copied := *b
+		clone[index] = &copied
 	}
-	defer iter.Close()
+	return clone
+}
 
-	var blueprint Blueprint
-	var line int
+func part1(blueprints Blueprints) string {
+	quality := puzzle.ParallelMap(blueprints, func(b *Blueprint) int {
+		b.Optimize(24)
+		return b.Quality()
+	})
+	var total int
+	for _, q := range quality {
+		total += q
+	}
+	return fmt.Sprint(total)
+}
+
+func part2(blueprints Blueprints) string {
+	const limit = 3
+	if len(blueprints) > limit {
+		blueprints = blueprints[:limit]
+	}
+	geodes := puzzle.ParallelMap(blueprints, func(b *Blueprint) int {
+		b.Optimize(32)
+		return b.MaxGeodes()
+	})
 	result := 1
-	for iter.Next() {
-		blueprint.Parse(iter.Value())
-		blueprint.Optimize(32)
-		result *= blueprint.MaxGeodes()
-		//fmt.Printf("%3d: %3d\n", blueprint.ID, blueprint.MaxGeodes())
-		line++
-		if line >= 3 {
-			break
-		}
+	for _, g := range geodes {
+		result *= g
 	}
 	return fmt.Sprint(result)
 }
@@ -5,9 +5,11 @@ import (
 
 	"fmt"
 	"strings"
+
+	"aoc2022/puzzle/puzzletest"
 )
 
-var workers = map[string](func(string) string){
+var workers = map[string](func(Blueprints) string){
 	"part1": part1,
 	"part2": part2,
 }
@@ -22,9 +24,19 @@ func TestSample(t *testing.T) {
 		{"part2", "sample.txt", fmt.Sprint(56 * 62)},
 		{"part1", "sample2.txt", "72"},
 	}
+	parsed := make(map[string]Blueprints)
 	for _, test := range tests {
 		t.Run(fmt.Sprintf("%s/%s", test.input, test.worker), func(t *testing.T) {
-			got := strings.TrimSpace(workers[test.worker](test.input))
+			input, ok := parsed[test.input]
+			if !ok {
+				var err error
+				input, err = ReadBlueprints(test.input)
+				if err != nil {
+					t.Fatal(err)
+				}
+				parsed[test.input] = input
+			}
+			got := strings.TrimSpace(workers[test.worker](input.Copy()))
 			want := strings.TrimSpace(test.result)
 			if got != want {
 				if strings.Contains(got, "\n") {
@@ -37,14 +49,35 @@ func TestSample(t *testing.T) {
 	}
 }
 
+// Optimize is called from ParallelMap workers, run with -race
+func TestConcurrent(t *testing.T) {
+	input, err := ReadBlueprints("sample.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	puzzletest.Concurrent(t, 4, "33", func() string {
+		return part1(input.Copy())
+	})
+}
+
 func BenchmarkPart1(b *testing.B) {
+	input, err := ReadBlueprints("sample.txt")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		part1("sample.txt")
+		part1(input.Copy())
 	}
 }
 
 func BenchmarkPart2(b *testing.B) {
+	input, err := ReadBlueprints("sample.txt")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		part2("sample.txt")
+		part2(input.Copy())
 	}
 }
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// Generate random puzzle input with the given number of coordinates
+func Generate(rng *rand.Rand, size int) (string, error) {
+	return GenerateCoordinates(rng, size)
+}
+
+// Generate encrypted file with exactly one zero value, like real inputs
+func GenerateCoordinates(rng *rand.Rand, count int) (string, error) {
+	const limit = 10000
+	if count < 3 {
+		return "", fmt.Errorf("encrypted file must contain at least 3 numbers: %d", count)
+	}
+	zero := rng.Intn(count)
+	var b strings.Builder
+	for i := 0; i < count; i++ {
+		var value int
+		for i != zero && value == 0 {
+			value = rng.Intn(2*limit-1) - limit + 1
+		}
+		fmt.Fprintln(&b, value)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"testing"
+
+	"fmt"
+	"math/rand"
+
+	"aoc2022/puzzle/puzzletest"
+)
+
+func TestGeneratedDecrypt(t *testing.T) {
+	const key = 811589153
+	puzzletest.Seeds(t, 20, func(t *testing.T, seed int64, rng *rand.Rand) {
+		count := 3 + int(seed)*7
+		input, err := GenerateCoordinates(rng, count)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ReadCoordinates(puzzletest.WriteInput(t, input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data.Size != int64(count) {
+			t.Fatalf("want %d items, got %d", count, data.Size)
+		}
+		want := make(map[int64]int)
+		for i, item := int64(0), data.First; i < data.Size; i, item = i+1, item.Next {
+			want[item.Value*key]++
+		}
+
+		data.Decrypt(key, 3)
+
+		if data.Zero.Value != 0 {
+			t.Errorf("zero item changed its value: %d", data.Zero.Value)
+		}
+		got := make(map[int64]int)
+		item := data.Zero
+		for i := int64(0); i < data.Size; i++ {
+			if item.Next.Prev != item || item.Prev.Next != item {
+				t.Fatalf("broken links around item #%d: %v", i, item)
+			}
+			got[item.Value]++
+			item = item.Next
+		}
+		if item != data.Zero {
+			t.Errorf("ring does not close after %d items", data.Size)
+		}
+		for value, n := range want {
+			if got[value] != n {
+				t.Errorf("value %d: want %d occurrences, got %d", value, n, got[value])
+			}
+		}
+	})
+	if _, err := Generate(rand.New(rand.NewSource(1)), 2); err == nil {
+		t.Errorf("no error for too few coordinates")
+	}
+}
+
+func BenchmarkGenerated(b *testing.B) {
+	for _, count := range []int{100, 1000, 5000} {
+		input, err := GenerateCoordinates(rand.New(rand.NewSource(1)), count)
+		if err != nil {
+			b.Fatal(err)
+		}
+		data, err := ReadCoordinates(puzzletest.WriteInput(b, input))
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(fmt.Sprintf("count=%d", count), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				data.Copy().Decrypt(811589153, 10)
+			}
+		})
+	}
+}
@@ -57,11 +57,11 @@ type Ring struct {
 	Size  int64
 }
 
-func ReadCoordinates(filename string) *Ring {
+func ReadCoordinates(filename string) (*Ring, error) {
 	var iter LineIterator
 	err := iter.Open(filename)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	defer iter.Close()
 
@@ -71,7 +71,7 @@ func ReadCoordinates(filename string) *Ring {
 		ring.Size++
 		value, err := strconv.Atoi(iter.Value())
 		if err != nil {
-			panic(err)
+			return nil, err
 		}
 		item := &RingItem{
 			Value: int64(value),
@@ -84,7 +84,7 @@ func ReadCoordinates(filename string) *Ring {
 		}
 		if value == 0 {
 			if ring.Zero != nil {
-				panic("second occurence of zero value in input")
+				return nil, fmt.Errorf("second occurence of zero value in input")
 			}
 			ring.Zero = item
 		}
@@ -93,9 +93,24 @@ func ReadCoordinates(filename string) *Ring {
 	ring.First.Prev = prev
 	prev.Next = ring.First
 	if ring.Zero == nil {
-		panic("zero value not found in input")
+		return nil, fmt.Errorf("zero value not found in input")
 	}
-	return &ring
+	return &ring, nil
+}
+
+// Deep copy of the ring, items are visited in current order starting from First
+func (r *Ring) Copy() *Ring {
+	clone := &Ring{}
+	item := r.First
+	var i int64
+	for i = 0; i < r.Size; i++ {
+		clone.Append(int(item.Value))
+		if item == r.Zero {
+			clone.Zero = clone.First.Prev
+		}
+		item = item.Next
+	}
+	return clone
 }
 
 func (r *Ring) Append(value ...int) {
@@ -22,7 +22,10 @@ func TestRing(t *testing.T) {
 }
 
 func TestDecryptSample(t *testing.T) {
-	data := ReadCoordinates("sample.txt")
+	data, err := ReadCoordinates("sample.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
 	data.Decrypt(811589153, 1)
 	firstRound := []int64{0, -2434767459, 3246356612, -1623178306, 2434767459, 1623178306, 811589153}
 
@@ -38,7 +41,10 @@ func TestDecryptSample(t *testing.T) {
 }
 
 func TestDecryptFull(t *testing.T) {
-	data := ReadCoordinates("sample.txt")
+	data, err := ReadCoordinates("sample.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
 	data.Decrypt(811589153, 10)
 
 	positions := map[int64]int64{
@@ -4,14 +4,12 @@ import (
 	"fmt"
 )
 
-func part1(filename string) string {
-	data := ReadCoordinates(filename)
+func part1(data *Ring) string {
 	data.Mix()
 	return fmt.Sprint(data.Coordinates())
 }
 
-func part2(filename string) string {
-	data := ReadCoordinates(filename)
+func part2(data *Ring) string {
 	data.Decrypt(811589153, 10)
 	return fmt.Sprint(data.Coordinates())
 }
@@ -50,6 +50,30 @@ func (gang *MonkeyGang) Get(name string) MonkeyNumber {
 	return result
 }
 
+func ReadMonkeyGang(filename string) (*MonkeyGang, error) {
+	gang := &MonkeyGang{}
+	err := gang.Parse(filename)
+	if err != nil {
+		return nil, err
+	}
+	return gang, nil
+}
+
+func (gang *MonkeyGang) Copy() *MonkeyGang {
+	clone := &MonkeyGang{
+		member: make(map[string]*Monkey, len(gang.member)),
+		cache:  make(map[string]MonkeyNumber, len(gang.cache)),
+	}
+	for name, monkey := range gang.member {
+		m := *monkey
+		clone.member[name] = &m
+	}
+	for name, number := range gang.cache {
+		clone.cache[name] = number
+	}
+	return clone
+}
+
 func (gang *MonkeyGang) Parse(filename string) error {
 	if gang.member == nil {
 		gang.member = make(map[string]*Monkey)
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+)
+
+// Try every number from zero up to the limit until root equation holds
+//
+// This is hopelessly slow for real inputs, but unlike SolveHuman it makes no
+// assumptions about how root equation responds to changes of human input.
+func (gang *MonkeyGang) ReferenceHuman(limit MonkeyNumber) (MonkeyNumber, error) {
+	root := gang.member["root"]
+	human := gang.member["humn"]
+	for human.Number = 0; human.Number <= limit; human.Number++ {
+		gang.cache = make(map[string]MonkeyNumber)
+		if gang.Get(root.Depends[0]) == gang.Get(root.Depends[1]) {
+			return human.Number, nil
+		}
+	}
+	return 0, fmt.Errorf("no solution for humn between 0 and %d", limit)
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"testing"
+
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"aoc2022/puzzle/puzzletest"
+)
+
+const humanLimit = 1000
+
+type monkeyInput map[string]Monkey
+
+func (input monkeyInput) String() string {
+	var lines []string
+	for name, monkey := range input {
+		if monkey.Job == Return {
+			lines = append(lines, fmt.Sprintf("%s: %d", name, monkey.Number))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s: %s %c %s", name, monkey.Depends[0], monkey.Job, monkey.Depends[1]))
+		}
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+func (input monkeyInput) Gang() *MonkeyGang {
+	gang := &MonkeyGang{
+		member: make(map[string]*Monkey),
+		cache:  make(map[string]MonkeyNumber),
+	}
+	for name, monkey := range input {
+		monkey := monkey
+		gang.member[name] = &monkey
+	}
+	return gang
+}
+
+type monkeyGenerator struct {
+	rng   *rand.Rand
+	input monkeyInput
+}
+
+func (g *monkeyGenerator) add(monkey Monkey) string {
+	for {
+		name := fmt.Sprintf("%c%c%c%c", 'a'+g.rng.Intn(26), 'a'+g.rng.Intn(26), 'a'+g.rng.Intn(26), 'a'+g.rng.Intn(26))
+		if _, taken := g.input[name]; taken || name == "root" || name == "humn" {
+			continue
+		}
+		g.input[name] = monkey
+		return name
+	}
+}
+
+func (g *monkeyGenerator) leaf(min, max int) (string, MonkeyNumber) {
+	value := MonkeyNumber(min + g.rng.Intn(max-min+1))
+	return g.add(Monkey{Job: Return, Number: value}), value
+}
+
+// Random subtree that does not depend on human input
+func (g *monkeyGenerator) constant(depth int) (string, MonkeyNumber) {
+	if depth == 0 || g.rng.Intn(3) == 0 {
+		return g.leaf(1, 20)
+	}
+	right, b := g.constant(depth - 1)
+	var left string
+	var a, value MonkeyNumber
+	job := [...]MonkeyJob{Add, Subtract, Multiply, Divide}[g.rng.Intn(4)]
+	if job == Divide && b > 0 { // make sure division yields a whole number
+		a = b * MonkeyNumber(1+g.rng.Intn(10))
+		left = g.add(Monkey{Job: Return, Number: a})
+	} else {
+		left, a = g.constant(depth - 1)
+	}
+	switch job {
+	case Add:
+		value = a + b
+	case Subtract:
+		value = a - b
+	case Multiply:
+		value = a * b
+	case Divide:
+		if b <= 0 {
+			job, value = Add, a+b
+		} else {
+			value = a / b
+		}
+	}
+	return g.add(Monkey{Job: job, Depends: [...]string{left, right}}), value
+}
+
+// Generate a valid puzzle with a single integer answer for human input
+//
+// Human input is transformed by a chain of additions, subtractions and
+// multiplications, so there is exactly one number that satisfies root
+// equation. Division is used only in subtrees that do not depend on human
+// input and always yields whole numbers.
+func generateMonkeys(rng *rand.Rand) (monkeyInput, MonkeyNumber) {
+	g := &monkeyGenerator{rng: rng, input: make(monkeyInput)}
+	answer := MonkeyNumber(rng.Intn(humanLimit + 1))
+	g.input["humn"] = Monkey{Job: Return, Number: MonkeyNumber(rng.Intn(humanLimit + 1))}
+
+	path, value := "humn", answer
+	for depth := rng.Intn(8); depth >= 0; depth-- {
+		var other string
+		var operand MonkeyNumber
+		job := [...]MonkeyJob{Add, Subtract, Multiply}[rng.Intn(3)]
+		if job == Multiply {
+			other, operand = g.leaf(1, 5)
+		} else {
+			other, operand = g.constant(2)
+		}
+		swap := rng.Intn(2) == 0
+		switch {
+		case job == Add:
+			value = value + operand
+		case job == Multiply:
+			value = value * operand
+		case job == Subtract && swap:
+			value = operand - value
+		case job == Subtract:
+			value = value - operand
+		}
+		depends := [...]string{path, other}
+		if swap {
+			depends[0], depends[1] = depends[1], depends[0]
+		}
+		path = g.add(Monkey{Job: job, Depends: depends})
+	}
+
+	other, operand := g.constant(3)
+	if operand <= value {
+		adjust := g.add(Monkey{Job: Return, Number: value - operand})
+		other = g.add(Monkey{Job: Add, Depends: [...]string{other, adjust}})
+	} else {
+		adjust := g.add(Monkey{Job: Return, Number: operand - value})
+		other = g.add(Monkey{Job: Subtract, Depends: [...]string{other, adjust}})
+	}
+	root := [...]string{path, other}
+	if rng.Intn(2) == 0 {
+		root[0], root[1] = root[1], root[0]
+	}
+	g.input["root"] = Monkey{Job: Add, Depends: root}
+	return g.input, answer
+}
+
+// Names of monkeys that root depends on, directly or indirectly
+func (input monkeyInput) reachable(name string, seen map[string]bool) map[string]bool {
+	if seen == nil {
+		seen = make(map[string]bool)
+	}
+	seen[name] = true
+	monkey := input[name]
+	if monkey.Job != Return {
+		for _, next := range monkey.Depends {
+			input.reachable(next, seen)
+		}
+	}
+	return seen
+}
+
+// Smaller inputs derived from the given one: unused monkeys are dropped,
+// an operation that does not depend on human input is replaced with its
+// result, or human starts yelling a smaller number
+func shrinkMonkeys(input monkeyInput) (smaller []monkeyInput) {
+	reachable := input.reachable("root", nil)
+	if len(reachable) < len(input) {
+		candidate := make(monkeyInput)
+		for name := range reachable {
+			candidate[name] = input[name]
+		}
+		smaller = append(smaller, candidate)
+	}
+
+	if number := input["humn"].Number; number > 0 {
+		candidate := make(monkeyInput)
+		for name, monkey := range input {
+			candidate[name] = monkey
+		}
+		candidate["humn"] = Monkey{Job: Return, Number: number / 2}
+		smaller = append(smaller, candidate)
+	}
+
+	gang := input.Gang()
+	var names []string
+	for name := range input {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if name == "root" || input[name].Job == Return || input.reachable(name, nil)["humn"] {
+			continue
+		}
+		candidate := make(monkeyInput)
+		candidate[name] = Monkey{Job: Return, Number: gang.Get(name)}
+		for other := range input.reachable("root", map[string]bool{name: true}) {
+			if other != name {
+				candidate[other] = input[other]
+			}
+		}
+		smaller = append(smaller, candidate)
+	}
+	return smaller
+}
+
+func fastHuman(input monkeyInput) (MonkeyNumber, error) {
+	return puzzletest.Recover(func() (MonkeyNumber, error) {
+		return input.Gang().SolveHuman(), nil
+	})
+}
+
+func mismatchMonkeys(input monkeyInput) bool {
+	want, err := input.Gang().ReferenceHuman(humanLimit)
+	if err != nil {
+		return false
+	}
+	got, err := fastHuman(input)
+	return err != nil || got != want
+}
+
+func TestDifferential(t *testing.T) {
+	for seed := int64(0); seed < 200; seed++ {
+		input, answer := generateMonkeys(rand.New(rand.NewSource(seed)))
+		want, err := input.Gang().ReferenceHuman(humanLimit)
+		if err != nil || want != answer {
+			t.Fatalf("seed=%d: generated invalid input (want %d, got %d, %v):\n%s", seed, answer, want, err, input)
+		}
+		if !mismatchMonkeys(input) {
+			continue
+		}
+		input = puzzletest.Shrink(input, shrinkMonkeys, mismatchMonkeys)
+		want, _ = input.Gang().ReferenceHuman(humanLimit)
+		got, err := fastHuman(input)
+		t.Errorf("seed=%d: solvers disagree: reference %d, fast %d (error: %v)\n%s", seed, want, got, err, input)
+	}
+}
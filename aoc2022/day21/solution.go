@@ -4,69 +4,60 @@ import (
 	"fmt"
 )
 
-func part1(filename string) string {
-	monkeys := MonkeyGang{}
-	err := monkeys.Parse(filename)
-	if err != nil {
-		panic(err)
-	}
+func part1(monkeys *MonkeyGang) string {
 	return fmt.Sprint(monkeys.Get("root"))
 }
 
-func part2(filename string) string {
-	monkeys := MonkeyGang{}
-	err := monkeys.Parse(filename)
-	if err != nil {
-		panic(err)
-	}
+func part2(monkeys *MonkeyGang) string {
+	return fmt.Sprint(monkeys.SolveHuman())
+}
 
+// Find the number to yell so that both sides of root equation match
+//
+// Root equation changes monotonically with human input, so we widen the
+// search range until the difference between both sides changes its sign and
+// then bisect that range down to the smallest matching number.
+func (monkeys *MonkeyGang) SolveHuman() MonkeyNumber {
 	root := monkeys.member["root"]
 	root.Job = Subtract
 	human := monkeys.member["humn"]
 
-	var iteration uint
-	var oldDelta, newDelta, step MonkeyNumber
-	oldDelta = monkeys.Get("root")
-	step = oldDelta
-	for oldDelta != 0 {
-		newDelta = monkeys.Get("root")
-		if newDelta == oldDelta && step != newDelta {
-			step *= 10
-		}
-		if (newDelta > 0) != (oldDelta > 0) || abs(newDelta) > abs(oldDelta) {
-			if abs(step) > 2 {
-				step /= -2
-			} else {
-				step /= -step
-			}
-		}
-		//fmt.Printf(
-		//	"iteration=%d human=%d oldDelta=%d newDelta=%d step=%d\n",
-		//	iteration,
-		//	human.Number,
-		//	oldDelta,
-		//	newDelta,
-		//	step,
-		//)
-		if iteration > 1000 {
-			panic("could not find answer reasonably fast")
+	delta := func(number MonkeyNumber) MonkeyNumber {
+		human.Number = number
+		return monkeys.Get("root")
+	}
+	sign := func(n MonkeyNumber) int {
+		switch {
+		case n > 0:
+			return 1
+		case n < 0:
+			return -1
 		}
-		if step == 0 {
-			panic("search without changing input will lead nowhere")
+		return 0
+	}
+
+	const ceiling = MonkeyNumber(1) << 50
+	var low, high MonkeyNumber
+	initial := sign(delta(low))
+	if initial == 0 {
+		return low
+	}
+	for high = 1; sign(delta(high)) == initial; high *= 2 {
+		if high > ceiling {
+			panic(fmt.Sprintf("root equation does not change sign between 0 and %d", ceiling))
 		}
-		oldDelta = newDelta
-		if oldDelta == 0 {
-			break
+		low = high
+	}
+	for high-low > 1 { // invariant: sign(delta(low)) == initial != sign(delta(high))
+		middle := low + (high-low)/2
+		if sign(delta(middle)) == initial {
+			low = middle
+		} else {
+			high = middle
 		}
-		human.Number += step
-		iteration++
 	}
-	return fmt.Sprint(human.Number)
-}
-
-func abs(n MonkeyNumber) MonkeyNumber {
-	if n < 0 {
-		return n * -1
+	if delta(high) != 0 {
+		panic(fmt.Sprintf("root equation has no integer solution between %d and %d", low, high))
 	}
-	return n
+	return high
 }
@@ -65,14 +65,23 @@ type Maze struct {
 	cube       *Cube
 }
 
-func (m *Maze) Load(filename string) {
+func ReadMaze(filename string) (*Maze, error) {
+	maze := &Maze{}
+	err := maze.Load(filename)
+	if err != nil {
+		return nil, err
+	}
+	return maze, nil
+}
+
+func (m *Maze) Load(filename string) (err error) {
 	var iter LineIterator
 	if err := iter.Open(filename); err != nil {
-		panic(err)
+		return err
 	}
 	defer func() {
-		if err := iter.Close(); err != nil {
-			panic(err)
+		if closeErr := iter.Close(); err == nil {
+			err = closeErr
 		}
 	}()
 
@@ -92,7 +101,7 @@ func (m *Maze) Load(filename string) {
 		}
 		if endOfMap {
 			if len(m.directions) != 0 {
-				panic("attempting to overwrite directions")
+				return fmt.Errorf("attempting to overwrite directions")
 			}
 			m.directions = iter.Value()
 			continue
@@ -101,7 +110,7 @@ func (m *Maze) Load(filename string) {
 			cursor.X++
 			switch char {
 			default:
-				panic(fmt.Sprintf("unsupported map tile: %c", char))
+				return fmt.Errorf("unsupported map tile: %c", char)
 			case '.':
 				m.tile[cursor] = Empty
 			case '#':
@@ -140,6 +149,31 @@ func (m *Maze) Load(filename string) {
 		location: Point{m.row[1].Min, 1},
 		facing:   Right,
 	}
+	return nil
+}
+
+// Deep copy of the maze, cube layout gets recalculated from scratch
+func (m *Maze) Copy() *Maze {
+	clone := &Maze{
+		tile:       make(map[Point]Cell, len(m.tile)),
+		row:        make(map[Coordinate]Boundary, len(m.row)),
+		col:        make(map[Coordinate]Boundary, len(m.col)),
+		directions: m.directions,
+		player:     m.player,
+	}
+	for point, cell := range m.tile {
+		clone.tile[point] = cell
+	}
+	for y, bounds := range m.row {
+		clone.row[y] = bounds
+	}
+	for x, bounds := range m.col {
+		clone.col[x] = bounds
+	}
+	if m.cube != nil {
+		clone.ParseCube()
+	}
+	return clone
 }
 
 func (m *Maze) ParseCube() {
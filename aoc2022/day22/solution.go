@@ -4,16 +4,12 @@ import (
 	"fmt"
 )
 
-func part1(filename string) string {
-	maze := &Maze{}
-	maze.Load(filename)
+func part1(maze *Maze) string {
 	maze.Play()
 	return fmt.Sprint(maze.player.Password())
 }
 
-func part2(filename string) string {
-	maze := &Maze{}
-	maze.Load(filename)
+func part2(maze *Maze) string {
 	maze.ParseCube()
 	maze.Play()
 	return fmt.Sprint(maze.player.Password())
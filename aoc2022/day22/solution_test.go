@@ -7,7 +7,7 @@ import (
 	"strings"
 )
 
-var workers = map[string](func(string) string){
+var workers = map[string](func(*Maze) string){
 	"part1": part1,
 	"part2": part2,
 }
@@ -22,9 +22,19 @@ func TestSolution(t *testing.T) {
 		{"part2", "sample.txt", "5031"},
 		{"part1", "input.txt", "122082"},
 	}
+	parsed := make(map[string]*Maze)
 	for _, test := range tests {
 		t.Run(fmt.Sprintf("%s/%s", test.input, test.worker), func(t *testing.T) {
-			got := strings.TrimSpace(workers[test.worker](test.input))
+			input, ok := parsed[test.input]
+			if !ok {
+				var err error
+				input, err = ReadMaze(test.input)
+				if err != nil {
+					t.Fatal(err)
+				}
+				parsed[test.input] = input
+			}
+			got := strings.TrimSpace(workers[test.worker](input.Copy()))
 			want := strings.TrimSpace(test.result)
 			if got != want {
 				if strings.Contains(got, "\n") {
@@ -38,13 +48,23 @@ func TestSolution(t *testing.T) {
 }
 
 func BenchmarkPart1(b *testing.B) {
+	input, err := ReadMaze("sample.txt")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		part1("sample.txt")
+		part1(input.Copy())
 	}
 }
 
 func BenchmarkPart2(b *testing.B) {
+	input, err := ReadMaze("sample.txt")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		part2("sample.txt")
+		part2(input.Copy())
 	}
 }
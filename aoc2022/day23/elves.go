@@ -51,14 +51,23 @@ func (group *ElfGroup) String() string {
 	return b.String()
 }
 
-func (group *ElfGroup) Load(filename string) {
+func ReadElves(filename string) (*ElfGroup, error) {
+	group := &ElfGroup{}
+	err := group.Load(filename)
+	if err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+func (group *ElfGroup) Load(filename string) (err error) {
 	var iter LineIterator
 	if err := iter.Open(filename); err != nil {
-		panic(err)
+		return err
 	}
 	defer func() {
-		if err := iter.Close(); err != nil {
-			panic(err)
+		if closeErr := iter.Close(); err == nil {
+			err = closeErr
 		}
 	}()
 
@@ -74,13 +83,26 @@ func (group *ElfGroup) Load(filename string) {
 			case '#':
 				err := group.elves.Add(cursor)
 				if err != nil {
-					panic(err)
+					return err
 				}
 			default:
-				panic(fmt.Sprintf("unsupported character at %v: %c", cursor, char))
+				return fmt.Errorf("unsupported character at %v: %c", cursor, char)
 			}
 		}
 	}
+	return nil
+}
+
+func (group *ElfGroup) Copy() *ElfGroup {
+	clone := &ElfGroup{
+		elves: make(PointSet, len(group.elves)),
+		max:   group.max,
+		min:   group.min,
+	}
+	for elf := range group.elves {
+		clone.elves[elf] = void{}
+	}
+	return clone
 }
 
 func (group *ElfGroup) updateRectangle() {
@@ -19,8 +19,10 @@ func TestLoad(t *testing.T) {
 	}
 	want = strings.TrimSpace(string(raw))
 
-	elves := &ElfGroup{}
-	elves.Load(filename)
+	elves, err := ReadElves(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	got = strings.TrimSpace(fmt.Sprint(elves))
 	if got != want {
@@ -42,8 +44,10 @@ func TestPlay(t *testing.T) {
 	filename := "sample.txt"
 
 	var got, want string
-	elves := &ElfGroup{}
-	elves.Load(filename)
+	elves, err := ReadElves(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
 	elves.Play(10)
 	got = strings.TrimSpace(fmt.Sprint(elves))
 
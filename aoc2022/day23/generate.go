@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// Generate random puzzle input: a square field of the given size
+func Generate(rng *rand.Rand, size int) (string, error) {
+	return GenerateGrove(rng, size, 0.5)
+}
+
+// Generate a square field of Elves with the given probability of each tile
+// being occupied. At least one Elf is always present.
+func GenerateGrove(rng *rand.Rand, size int, density float64) (string, error) {
+	if size < 1 {
+		return "", fmt.Errorf("field size must be positive: %d", size)
+	}
+	tiles := make([][]byte, size)
+	var elves int
+	for y := range tiles {
+		tiles[y] = make([]byte, size)
+		for x := range tiles[y] {
+			tiles[y][x] = '.'
+			if rng.Float64() < density {
+				tiles[y][x] = '#'
+				elves++
+			}
+		}
+	}
+	if elves == 0 {
+		tiles[rng.Intn(size)][rng.Intn(size)] = '#'
+	}
+	var b strings.Builder
+	for _, row := range tiles {
+		b.Write(row)
+		b.WriteRune('\n')
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+
+	"fmt"
+	"math/rand"
+
+	"aoc2022/puzzle/puzzletest"
+)
+
+func TestGeneratedGrove(t *testing.T) {
+	puzzletest.Seeds(t, 20, func(t *testing.T, seed int64, rng *rand.Rand) {
+		size := 1 + int(seed)
+		density := float64(seed%5+1) / 6
+		input, err := GenerateGrove(rng, size, density)
+		if err != nil {
+			t.Fatal(err)
+		}
+		elves, err := ReadElves(puzzletest.WriteInput(t, input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		count := len(elves.elves)
+		if count == 0 || count > size*size {
+			t.Fatalf("unexpected number of elves in %dx%d field: %d", size, size, count)
+		}
+
+		elves.Play(10)
+		if len(elves.elves) != count {
+			t.Errorf("elf count changed: was %d, now %d", count, len(elves.elves))
+		}
+		if elves.Result() < 0 {
+			t.Errorf("negative number of empty tiles: %d", elves.Result())
+		}
+
+		const maxRounds = 10000
+		if rounds := elves.Play(maxRounds); rounds == maxRounds {
+			t.Errorf("movements did not cease after %d rounds", maxRounds)
+		}
+	})
+	if _, err := Generate(rand.New(rand.NewSource(1)), 0); err == nil {
+		t.Errorf("no error for empty field")
+	}
+}
+
+func BenchmarkGenerated(b *testing.B) {
+	for _, size := range []int{10, 40, 80} {
+		input, err := GenerateGrove(rand.New(rand.NewSource(1)), size, 0.5)
+		if err != nil {
+			b.Fatal(err)
+		}
+		elves, err := ReadElves(puzzletest.WriteInput(b, input))
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				part1(elves.Copy())
+			}
+		})
+	}
+}
@@ -4,17 +4,12 @@ import (
 	"fmt"
 )
 
-func part1(filename string) string {
-	var elves = &ElfGroup{}
-	elves.Load(filename)
+func part1(elves *ElfGroup) string {
 	elves.Play(10)
 	return fmt.Sprint(elves.Result())
 }
 
-func part2(filename string) string {
-	var elves = &ElfGroup{}
-	elves.Load(filename)
-
+func part2(elves *ElfGroup) string {
 	const maxRounds = 10000
 	result := elves.Play(maxRounds)
 	if result == maxRounds {
@@ -33,6 +33,26 @@ func (bb *BlizzardBasin) String() string {
 	)
 }
 
+func ReadBasin(filename string) (*BlizzardBasin, error) {
+	input, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	basin := &BlizzardBasin{}
+	basin.Parse(input)
+	return basin, nil
+}
+
+func (bb *BlizzardBasin) Copy() *BlizzardBasin {
+	clone := *bb
+	clone.blizzard = append([]Blizzard(nil), bb.blizzard...)
+	clone.wall = make(PointSet, len(bb.wall))
+	for point := range bb.wall {
+		clone.wall.Add(point)
+	}
+	return &clone
+}
+
 func (bb *BlizzardBasin) Load(filename string) {
 	var input []byte
 	var err error
@@ -40,7 +60,10 @@ func (bb *BlizzardBasin) Load(filename string) {
 	if err != nil {
 		panic(err)
 	}
+	bb.Parse(input)
+}
 
+func (bb *BlizzardBasin) Parse(input []byte) {
 	var tile byte
 	var cursor Point
 	bb.wall = make(PointSet)
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+)
+
+// Find the shortest path by tracking every position reachable at each minute
+//
+// This is much slower than Search.ShortestPath and shares nothing with it
+// beyond parsed input: blizzards are moved one step per minute instead of
+// being computed from the cycle phase, and a position is reachable at the
+// next minute if it is reachable now from any open neighbor, with no
+// states skipped as already seen.
+func (bb *BlizzardBasin) ReferencePath(from, to Point, startTime int) (int, error) {
+	width, height := int(bb.width), int(bb.height)
+	grid := func() [][]bool {
+		rows := make([][]bool, height)
+		for y := range rows {
+			rows[y] = make([]bool, width)
+		}
+		return rows
+	}
+
+	blizzards := make([]Blizzard, len(bb.blizzard))
+	copy(blizzards, bb.blizzard)
+	step := func() {
+		for i := range blizzards {
+			b := &blizzards[i]
+			b.spawn = b.spawn.Look(b.direction)
+			switch {
+			case b.spawn.X == 0:
+				b.spawn.X = bb.width - 2
+			case b.spawn.X == bb.width-1:
+				b.spawn.X = 1
+			case b.spawn.Y == 0:
+				b.spawn.Y = bb.height - 2
+			case b.spawn.Y == bb.height-1:
+				b.spawn.Y = 1
+			}
+		}
+	}
+	for i := 0; i < startTime; i++ {
+		step()
+	}
+
+	// Basin state repeats after width*height minutes at most,
+	// so there can not be more distinct (position, minute) pairs than that
+	limit := width * height * width * height
+
+	reachable := grid()
+	reachable[from.Y][from.X] = true
+	for minute := 0; minute < limit; minute++ {
+		if reachable[to.Y][to.X] {
+			return minute, nil
+		}
+		step()
+		blocked := grid()
+		for _, b := range blizzards {
+			blocked[b.spawn.Y][b.spawn.X] = true
+		}
+		next := grid()
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				position := Point{ScaleUnit(x), ScaleUnit(y)}
+				if blocked[y][x] || bb.wall.Contains(position) {
+					continue
+				}
+				for _, d := range [...]Direction{{0, 0}, {0, -1}, {0, 1}, {-1, 0}, {1, 0}} {
+					prev := position.Look(d)
+					if prev.X >= 0 && prev.Y >= 0 && int(prev.X) < width && int(prev.Y) < height && reachable[prev.Y][prev.X] {
+						next[y][x] = true
+						break
+					}
+				}
+			}
+		}
+		reachable = next
+	}
+	return 0, fmt.Errorf("no path from %v to %v within %d minutes", from, to, limit)
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"testing"
+
+	"math/rand"
+	"strings"
+
+	"aoc2022/puzzle/puzzletest"
+)
+
+// Tiles inside the basin walls, entrance and exit are added when rendering
+type basinInput [][]byte
+
+func (input basinInput) String() string {
+	width := len(input[0]) + 2
+	var b strings.Builder
+	b.WriteString("#." + strings.Repeat("#", width-2) + "\n")
+	for _, row := range input {
+		b.WriteString("#" + string(row) + "#\n")
+	}
+	b.WriteString(strings.Repeat("#", width-2) + ".#\n")
+	return b.String()
+}
+
+func (input basinInput) Basin() *BlizzardBasin {
+	basin := &BlizzardBasin{}
+	basin.Parse([]byte(input.String()))
+	return basin
+}
+
+func generateBasin(rng *rand.Rand) basinInput {
+	width, height := 1+rng.Intn(8), 1+rng.Intn(6)
+	density := rng.Float64() * 0.6
+	const icons = "^v<>"
+	input := make(basinInput, height)
+	for y := range input {
+		input[y] = make([]byte, width)
+		for x := range input[y] {
+			input[y][x] = '.'
+			if rng.Float64() < density {
+				input[y][x] = icons[rng.Intn(len(icons))]
+			}
+		}
+	}
+	return input
+}
+
+// Smaller inputs derived from the given one: without a row, without a
+// column or without a single blizzard
+func shrinkBasin(input basinInput) (smaller []basinInput) {
+	for y := 0; y < len(input) && len(input) > 1; y++ {
+		candidate := make(basinInput, 0, len(input)-1)
+		candidate = append(candidate, input[:y]...)
+		candidate = append(candidate, input[y+1:]...)
+		smaller = append(smaller, candidate)
+	}
+	for x := 0; x < len(input[0]) && len(input[0]) > 1; x++ {
+		candidate := make(basinInput, len(input))
+		for y, row := range input {
+			candidate[y] = append(append([]byte{}, row[:x]...), row[x+1:]...)
+		}
+		smaller = append(smaller, candidate)
+	}
+	for y, row := range input {
+		for x, tile := range row {
+			if tile == '.' {
+				continue
+			}
+			candidate := make(basinInput, len(input))
+			for i := range input {
+				candidate[i] = append([]byte{}, input[i]...)
+			}
+			candidate[y][x] = '.'
+			smaller = append(smaller, candidate)
+		}
+	}
+	return smaller
+}
+
+// Durations of three trips: there, back and there again
+func fastTrips(input basinInput) ([3]int, error) {
+	return puzzletest.Recover(func() (trips [3]int, err error) {
+		basin := input.Basin()
+		search := Search{basin: basin}
+		var commute int
+		for i, endpoints := range [...][2]Point{
+			{basin.entrance, basin.exit},
+			{basin.exit, basin.entrance},
+			{basin.entrance, basin.exit},
+		} {
+			trips[i], err = search.ShortestPath(endpoints[0], endpoints[1], commute)
+			if err != nil {
+				return trips, err
+			}
+			commute += trips[i]
+		}
+		return trips, nil
+	})
+}
+
+func referenceTrips(input basinInput) (trips [3]int, err error) {
+	basin := input.Basin()
+	var commute int
+	for i, endpoints := range [...][2]Point{
+		{basin.entrance, basin.exit},
+		{basin.exit, basin.entrance},
+		{basin.entrance, basin.exit},
+	} {
+		trips[i], err = basin.ReferencePath(endpoints[0], endpoints[1], commute)
+		if err != nil {
+			return trips, err
+		}
+		commute += trips[i]
+	}
+	return trips, nil
+}
+
+func mismatchBasin(input basinInput) bool {
+	want, err := referenceTrips(input)
+	if err != nil {
+		return false
+	}
+	got, err := fastTrips(input)
+	return err != nil || got != want
+}
+
+func TestDifferential(t *testing.T) {
+	for seed := int64(0); seed < 200; seed++ {
+		input := generateBasin(rand.New(rand.NewSource(seed)))
+		if !mismatchBasin(input) {
+			continue
+		}
+		input = puzzletest.Shrink(input, shrinkBasin, mismatchBasin)
+		want, _ := referenceTrips(input)
+		got, err := fastTrips(input)
+		t.Errorf("seed=%d: solvers disagree: reference %v, fast %v (error: %v)\n%s", seed, want, got, err, input)
+	}
+}
+
+func TestReferenceSample(t *testing.T) {
+	basin, err := ReadBasin("sample.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var commute int
+	for i, trip := range []struct {
+		from, to Point
+		want     int
+	}{
+		{basin.entrance, basin.exit, 18},
+		{basin.exit, basin.entrance, 23},
+		{basin.entrance, basin.exit, 13},
+	} {
+		got, err := basin.ReferencePath(trip.from, trip.to, commute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != trip.want {
+			t.Errorf("trip %d: want %d minutes, got %d", i+1, trip.want, got)
+		}
+		commute += got
+	}
+}
@@ -2,31 +2,16 @@ package main
 
 import (
 	"fmt"
-	"sort"
 )
 
-type SearchCursor struct {
-	location    Point
-	destination Point
-	round       int
-}
-
-func (cursor SearchCursor) Move(in Direction) SearchCursor {
-	cursor.round++
-	cursor.location = cursor.location.Look(in)
-	return cursor
-}
-
 type Search struct {
-	basin     *BlizzardBasin
-	proximity []ScaleUnit
-	cache     []PointSet
-	seen      map[SearchCursor]bool
+	basin *BlizzardBasin
+	cache []PointSet // blizzard locations for each minute of the period
 }
 
 var Stay = Direction{0, 0}
 
-var Moves = []Direction{
+var Moves = [...]Direction{
 	Down,
 	Right,
 	Stay,
@@ -34,96 +19,76 @@ var Moves = []Direction{
 	Left,
 }
 
-func (search *Search) ShortestPath(from, to Point, startTime int) int {
-	search.seen = make(map[SearchCursor]bool)
-
-	distance := from.Distance(to)
-	search.proximity = make([]ScaleUnit, startTime+1)
-	for i := 0; i <= startTime; i++ {
-		search.proximity[i] = distance
-	}
-
-	search.recurse(SearchCursor{
-		location:    from,
-		destination: to,
-		round:       startTime,
-	})
-	for index := startTime; index < len(search.proximity); index++ {
-		if search.proximity[index] == 0 {
-			return index - startTime
-		}
-	}
-	fmt.Println("Best proximity by round:", search.proximity)
-	panic("search did not reach the target, consider increasing backtrackThreshold")
+// Position of the expedition within the blizzard cycle
+type searchState struct {
+	location Point
+	phase    int
 }
 
-func (search *Search) recurse(cursor SearchCursor) (ok bool) {
-	// Avoid infinite loops
-	if search.seen[cursor] {
-		return false
-	}
-	search.seen[cursor] = true
-
-	// Termination condition: failure
-	_, isWall := search.basin.wall[cursor.location]
-	if isWall {
-		return false
-	}
-	if search.isBlizzard(cursor) {
-		return false
-	}
-
-	// Termination condition: success
-	distance := cursor.location.Distance(cursor.destination)
-
-	if cursor.round > len(search.proximity) {
-		panic("missed a proximity record in one of previous steps")
-	}
-	if cursor.round == len(search.proximity) {
-		search.proximity = append(search.proximity, distance)
-	}
-	if search.proximity[cursor.round] > distance {
-		search.proximity[cursor.round] = distance
-	}
-	if distance == 0 {
-		return true
-	}
-
-	// Limit backtracking
-	const backtrackThreshold = 30
-	for i := 0; i < cursor.round-backtrackThreshold; i++ {
-		if search.proximity[i] < distance {
-			return false // another shorter path has achieved a significantly better result
-		}
-		if search.proximity[i] <= 1 {
-			return false // we have already found a shorter path to the target
+// Find the shortest path with breadth-first search
+//
+// Blizzards return to their initial positions every lcm(w, h) minutes,
+// where w and h are the dimensions of the basin without walls. Arriving at
+// the same position at the same phase of that cycle for the second time
+// can not lead to a faster route, so each such state is visited once.
+// There are finitely many states, so the search ends with an error when
+// the target can not be reached at all.
+//
+// This replaces the former depth-first search that limited backtracking
+// with a fixed threshold and could miss the shortest path.
+func (search *Search) ShortestPath(from, to Point, startTime int) (int, error) {
+	basin := search.basin
+	period := search.period()
+	seen := map[searchState]bool{{from, startTime % period}: true}
+	frontier := []Point{from}
+	for round := startTime; len(frontier) > 0; round++ {
+		blizzards := search.blizzards(round + 1)
+		var next []Point
+		for _, location := range frontier {
+			if location == to {
+				return round - startTime, nil
+			}
+			for _, direction := range Moves {
+				dest := location.Look(direction)
+				if dest.X < 0 || dest.Y < 0 || dest.X >= basin.width || dest.Y >= basin.height {
+					continue
+				}
+				if basin.wall.Contains(dest) || blizzards.Contains(dest) {
+					continue
+				}
+				state := searchState{dest, (round + 1) % period}
+				if seen[state] {
+					continue
+				}
+				seen[state] = true
+				next = append(next, dest)
+			}
 		}
+		frontier = next
 	}
+	return 0, fmt.Errorf("no path from %v to %v", from, to)
+}
 
-	// Try all allowed moves
-	var next SearchCursor
-	var moved bool
-	sort.Slice(Moves, func(i, j int) bool {
-		iDistance := cursor.location.Look(Moves[i]).Distance(cursor.destination)
-		jDistance := cursor.location.Look(Moves[j]).Distance(cursor.destination)
-		return iDistance < jDistance
-	})
-	for _, direction := range Moves {
-		next = cursor.Move(direction)
-		if search.recurse(next) {
-			moved = true
+// Number of minutes after which blizzards repeat their positions
+func (search *Search) period() int {
+	a, b := int(search.basin.width-2), int(search.basin.height-2)
+	gcd := func(a, b int) int {
+		for b != 0 {
+			a, b = b, a%b
 		}
+		return a
 	}
-	return moved
+	return a / gcd(a, b) * b
 }
 
-func (search *Search) isBlizzard(cursor SearchCursor) bool {
-	// Populate blizzard cache
-	for !(cursor.round < len(search.cache)) {
-		search.cache = append(search.cache, search.basin.Blizzards(len(search.cache)))
+// Blizzard locations at the given minute
+func (search *Search) blizzards(round int) PointSet {
+	if search.cache == nil {
+		search.cache = make([]PointSet, search.period())
 	}
-
-	// Check if cursor location is blocked by a blizzard in current round
-	_, blocked := search.cache[cursor.round][cursor.location]
-	return blocked
+	phase := round % len(search.cache)
+	if search.cache[phase] == nil {
+		search.cache[phase] = search.basin.Blizzards(phase)
+	}
+	return search.cache[phase]
 }
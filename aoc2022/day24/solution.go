@@ -2,42 +2,38 @@ package main
 
 import (
 	"fmt"
+	"log"
 )
 
-func part1(filename string) string {
-	basin := &BlizzardBasin{}
-	basin.Load(filename)
+func part1(basin *BlizzardBasin) string {
 	fmt.Println(basin)
 
 	search := Search{basin: basin}
-	commute := search.ShortestPath(
+	commute, err := search.ShortestPath(
 		basin.entrance,
 		basin.exit,
 		0,
 	)
+	if err != nil {
+		log.Fatal(err)
+	}
 	return fmt.Sprint(commute)
 }
 
-func part2(filename string) string {
-	basin := &BlizzardBasin{}
-	basin.Load(filename)
+func part2(basin *BlizzardBasin) string {
 	search := Search{basin: basin}
 
 	var commute int
-	commute += search.ShortestPath(
-		basin.entrance,
-		basin.exit,
-		commute,
-	)
-	commute += search.ShortestPath(
-		basin.exit,
-		basin.entrance,
-		commute,
-	)
-	commute += search.ShortestPath(
-		basin.entrance,
-		basin.exit,
-		commute,
-	)
+	for _, trip := range [...][2]Point{
+		{basin.entrance, basin.exit},
+		{basin.exit, basin.entrance},
+		{basin.entrance, basin.exit},
+	} {
+		duration, err := search.ShortestPath(trip[0], trip[1], commute)
+		if err != nil {
+			log.Fatal(err)
+		}
+		commute += duration
+	}
 	return fmt.Sprint(commute)
 }
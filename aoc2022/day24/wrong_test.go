@@ -7,7 +7,11 @@ import (
 )
 
 func TestWrongValue(t *testing.T) {
-	result, err := strconv.Atoi(part2("input.txt"))
+	basin, err := ReadBasin("input.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := strconv.Atoi(part2(basin))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -22,33 +26,38 @@ func TestSamplePart2(t *testing.T) {
 	search := Search{basin: basin}
 
 	var commute int
-	commute += search.ShortestPath(
-		basin.entrance,
-		basin.exit,
-		commute,
-	)
+	trip, err := search.ShortestPath(basin.entrance, basin.exit, commute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commute += trip
 	want := 18
 	if commute != want {
 		t.Errorf("first trip: got %d, want %d", commute, want)
 	}
 
-	commute += search.ShortestPath(
-		basin.exit,
-		basin.entrance,
-		commute,
-	)
+	trip, err = search.ShortestPath(basin.exit, basin.entrance, commute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commute += trip
 	want = 18 + 23
 	if commute != want {
 		t.Errorf("second trip: got %d, want %d", commute, want)
 	}
 
-	commute += search.ShortestPath(
-		basin.entrance,
-		basin.exit,
-		commute,
-	)
+	trip, err = search.ShortestPath(basin.entrance, basin.exit, commute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commute += trip
 	want = 18 + 23 + 13
 	if commute != want {
 		t.Errorf("third trip: got %d, want %d", commute, want)
 	}
+
+	_, err = search.ShortestPath(basin.entrance, Point{0, 0}, commute)
+	if err == nil {
+		t.Errorf("path into the wall corner found")
+	}
 }
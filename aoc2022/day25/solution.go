@@ -1,22 +1,42 @@
 package main
 
-func part1(filename string) string {
+import (
+	"fmt"
+)
+
+type SnafuNumbers []SnafuNumber
+
+func ReadSnafuNumbers(filename string) (numbers SnafuNumbers, err error) {
 	var iter LineIterator
-	if err := iter.Open(filename); err != nil {
-		panic(err)
+	err = iter.Open(filename)
+	if err != nil {
+		return nil, err
 	}
+	defer iter.Close()
 
-	var total, cursor SnafuNumber
 	for iter.Next() {
-		err := cursor.Parse(iter.Value())
+		var number SnafuNumber
+		err = number.Parse(iter.Value())
 		if err != nil {
-			panic(err)
+			return nil, fmt.Errorf("line %d: %w", len(numbers)+1, err)
 		}
-		total += cursor
+		numbers = append(numbers, number)
+	}
+	return numbers, iter.Error()
+}
+
+func (numbers SnafuNumbers) Copy() SnafuNumbers {
+	return append(SnafuNumbers(nil), numbers...)
+}
+
+func part1(numbers SnafuNumbers) string {
+	var total SnafuNumber
+	for _, number := range numbers {
+		total += number
 	}
 	return total.String()
 }
 
-func part2(filename string) string {
+func part2(numbers SnafuNumbers) string {
 	return ""
 }
@@ -0,0 +1,30 @@
+package puzzle
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+const answerPrefix = "Your puzzle answer was "
+
+// Read accepted answers from puzzle description saved after solving it
+//
+// Answers are returned in order of appearance, i.e. part 1 goes first.
+func ReadAnswers(filename string) (answers []string, err error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, answerPrefix) {
+			continue
+		}
+		answers = append(answers, strings.TrimSuffix(line[len(answerPrefix):], "."))
+	}
+	return answers, scanner.Err()
+}
@@ -0,0 +1,104 @@
+package puzzle
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sync"
+)
+
+// Create file and fill it with the output of write function
+//
+// Errors from writing and from closing the file are both reported.
+func WriteFile(filename string, write func(io.Writer) error) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	err = write(file)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Values read from file in background
+//
+// Consumers range over C and call Close when done, even if they stopped
+// before the end of file: that stops the reader and releases the file.
+type Stream[T any] struct {
+	C    <-chan T
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+	err  error
+}
+
+// Read text file line by line
+func ReadLines(filename string) (*Stream[string], error) {
+	return stream(filename, func(r io.Reader, emit func(string) bool) error {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			if !emit(scanner.Text()) {
+				return nil
+			}
+		}
+		return scanner.Err()
+	})
+}
+
+// Read text file rune by rune
+func ReadChars(filename string) (*Stream[rune], error) {
+	return stream(filename, func(r io.Reader, emit func(rune) bool) error {
+		reader := bufio.NewReader(r)
+		for {
+			char, _, err := reader.ReadRune()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if !emit(char) {
+				return nil
+			}
+		}
+	})
+}
+
+func stream[T any](filename string, read func(r io.Reader, emit func(T) bool) error) (*Stream[T], error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	values := make(chan T)
+	s := &Stream[T]{
+		C:    values,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go func() {
+		defer close(s.done)
+		defer close(values)
+		defer file.Close()
+		s.err = read(file, func(value T) bool {
+			select {
+			case values <- value:
+				return true
+			case <-s.stop:
+				return false
+			}
+		})
+	}()
+	return s, nil
+}
+
+// Stop reading and report the error that interrupted it, if any
+//
+// Close may be called more than once.
+func (s *Stream[T]) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	<-s.done
+	return s.err
+}
@@ -0,0 +1,78 @@
+package puzzle
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "output.txt")
+	err := WriteFile(filename, func(w io.Writer) error {
+		_, err := io.WriteString(w, "hello")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(filename)
+	if err != nil || string(content) != "hello" {
+		t.Errorf("want %q, got %q (%v)", "hello", content, err)
+	}
+
+	failure := errors.New("write failed")
+	err = WriteFile(filename, func(io.Writer) error { return failure })
+	if err != failure {
+		t.Errorf("want %v, got %v", failure, err)
+	}
+	err = WriteFile(filepath.Join(filename, "nested"), func(io.Writer) error { return nil })
+	if err == nil {
+		t.Errorf("file created inside another file")
+	}
+}
+
+func TestReadLines(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "input.txt")
+	err := os.WriteFile(filename, []byte("a\nb\n\nc\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines, err := ReadLines(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for line := range lines.C {
+		got = append(got, line)
+	}
+	if err = lines.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprintf("%q", got) != `["a" "b" "" "c"]` {
+		t.Errorf("unexpected lines: %q", got)
+	}
+
+	chars, err := ReadChars(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if char := <-chars.C; char != 'a' {
+		t.Errorf("want first char 'a', got %q", char)
+	}
+	for i := 0; i < 2; i++ { // stop early, repeatedly
+		if err = chars.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, ok := <-chars.C; ok {
+		t.Errorf("stream was not closed after stopping")
+	}
+
+	_, err = ReadLines(filepath.Join(t.TempDir(), "missing.txt"))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("want error for missing file, got %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package puzzle
+
+import (
+	"runtime"
+	"sync"
+)
+
+// Number of worker goroutines for ParallelMap (0 means GOMAXPROCS)
+var jobs int
+
+// Number of workers to be used for a batch of the given size
+func Workers(batch int) int {
+	limit := jobs
+	if limit <= 0 || limit > runtime.GOMAXPROCS(0) {
+		limit = runtime.GOMAXPROCS(0)
+	}
+	if limit > batch {
+		limit = batch
+	}
+	if limit < 1 {
+		limit = 1
+	}
+	return limit
+}
+
+// Apply worker function to all input values concurrently
+//
+// Results are stored at the same index as the corresponding input value,
+// so the output does not depend on goroutine scheduling.
+func ParallelMap[T, R any](input []T, worker func(T) R) []R {
+	result := make([]R, len(input))
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < Workers(len(input)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range queue {
+				result[index] = worker(input[index])
+			}
+		}()
+	}
+	for index := range input {
+		queue <- index
+	}
+	close(queue)
+	wg.Wait()
+	return result
+}
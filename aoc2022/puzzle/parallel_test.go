@@ -0,0 +1,33 @@
+package puzzle
+
+import (
+	"testing"
+)
+
+func TestParallelMap(t *testing.T) {
+	input := make([]int, 1000)
+	for i := range input {
+		input[i] = i
+	}
+	square := func(n int) int {
+		return n * n
+	}
+
+	defer func(saved int) { jobs = saved }(jobs)
+	for _, jobs = range []int{0, 1, 2, 7, 1000} {
+		got := ParallelMap(input, square)
+		if len(got) != len(input) {
+			t.Fatalf("jobs=%d: want %d results, got %d", jobs, len(input), len(got))
+		}
+		for i, value := range got {
+			if value != i*i {
+				t.Errorf("jobs=%d: result #%d: want %d, got %d", jobs, i, i*i, value)
+				break
+			}
+		}
+	}
+
+	if got := ParallelMap([]int{}, square); len(got) != 0 {
+		t.Errorf("empty input produced results: %v", got)
+	}
+}
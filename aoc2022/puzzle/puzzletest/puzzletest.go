@@ -0,0 +1,87 @@
+// Helpers shared by tests of daily solutions
+package puzzletest
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// Save content to a temporary input file, return its name
+func WriteInput(tb testing.TB, content string) string {
+	tb.Helper()
+	filename := filepath.Join(tb.TempDir(), "input.txt")
+	err := os.WriteFile(filename, []byte(content), 0644)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return filename
+}
+
+// Run test as a separate subtest for each seed from 0 to count-1
+//
+// Every subtest gets its own random source initialized with the seed, so
+// that a failing case can be reproduced on its own with -run.
+func Seeds(t *testing.T, count int64, test func(t *testing.T, seed int64, rng *rand.Rand)) {
+	t.Helper()
+	for seed := int64(0); seed < count; seed++ {
+		seed := seed
+		t.Run(fmt.Sprintf("seed=%d", seed), func(t *testing.T) {
+			test(t, seed, rand.New(rand.NewSource(seed)))
+		})
+	}
+}
+
+// Greedily replace the input with a smaller one while the check keeps failing
+func Shrink[T any](input T, smaller func(T) []T, failing func(T) bool) T {
+	for {
+		var found bool
+		for _, candidate := range smaller(input) {
+			if failing(candidate) {
+				input = candidate
+				found = true
+				break
+			}
+		}
+		if !found {
+			return input
+		}
+	}
+}
+
+// Call solver, report panic as an error instead of crashing the test
+func Recover[T any](solve func() (T, error)) (result T, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return solve()
+}
+
+// Run solve from several goroutines at once and compare each result with
+// the expected one
+//
+// Solutions that split their work with puzzle.ParallelMap should pass this
+// check under the race detector.
+func Concurrent(t *testing.T, goroutines int, want string, solve func() string) {
+	t.Helper()
+	results := make([]string, goroutines)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = solve()
+		}(i)
+	}
+	wg.Wait()
+	for i, got := range results {
+		if got != want {
+			t.Errorf("goroutine %d: want %q, got %q", i, want, got)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package puzzle
+
+import (
+	"fmt"
+	"sort"
+)
+
+type key struct {
+	year, day int
+}
+
+var registry = make(map[key]Solver)
+
+// Make solver available to Main
+//
+// Registering two solvers for the same day is a programming error.
+func Register(solver Solver) {
+	info := solver.Info()
+	k := key{info.Year, info.Day}
+	if _, taken := registry[k]; taken {
+		panic(fmt.Sprintf("solver already registered for %d/%02d", info.Year, info.Day))
+	}
+	registry[k] = solver
+}
+
+// Find solver for the given day
+func Lookup(year, day int) (solver Solver, ok bool) {
+	solver, ok = registry[key{year, day}]
+	return solver, ok
+}
+
+// All registered solvers, in chronological order
+func Registered() []Solver {
+	solvers := make([]Solver, 0, len(registry))
+	for _, solver := range registry {
+		solvers = append(solvers, solver)
+	}
+	sort.Slice(solvers, func(i, j int) bool {
+		a, b := solvers[i].Info(), solvers[j].Info()
+		if a.Year != b.Year {
+			return a.Year < b.Year
+		}
+		return a.Day < b.Day
+	})
+	return solvers
+}
+
+// Choose a solver by year and day, both may be omitted when there is only
+// one solver to choose from
+func selectSolver(year, day int) (Solver, error) {
+	if year == 0 && day == 0 {
+		solvers := Registered()
+		if len(solvers) != 1 {
+			return nil, fmt.Errorf("%d solvers registered, use -year and -day to choose one", len(solvers))
+		}
+		return solvers[0], nil
+	}
+	solver, ok := Lookup(year, day)
+	if !ok {
+		return nil, fmt.Errorf("no solver registered for %d/%02d", year, day)
+	}
+	return solver, nil
+}
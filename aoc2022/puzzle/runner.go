@@ -0,0 +1,248 @@
+package puzzle
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"time"
+)
+
+const numberOfParts = 2
+
+// Command line entrypoint shared by all solutions
+func Main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+var errMismatch = errors.New("some results do not match accepted answers")
+
+func run() error {
+	cpuprofile := flag.String("cpuprofile", "", "write cpu profile to `file`")
+	memprofile := flag.String("memprofile", "", "write memory profile to `file`")
+
+	input := flag.String("input", "input.txt", "input data for today's challenge")
+	part := flag.Int("part", 0, "puzzle part")
+	check := flag.Bool("check", false, "compare results with accepted answers from README next to input file")
+	bench := flag.Int("bench", 0, "solve each part `N` times and report average duration")
+
+	year := flag.Int("year", 0, "puzzle `year` (may be omitted if only one solver is registered)")
+	day := flag.Int("day", 0, "puzzle `day` (may be omitted if only one solver is registered)")
+	list := flag.Bool("list", false, "print registered solvers and exit")
+
+	// Flags for optional features are only available when some registered
+	// solver supports them
+	var canGenerate, canQuery, canJobs bool
+	for _, solver := range Registered() {
+		_, generates := generatorOf(solver)
+		_, queries := querierOf(solver)
+		canGenerate = canGenerate || generates
+		canQuery = canQuery || queries
+		canJobs = canJobs || usesJobs(solver)
+	}
+	var generate *int
+	var seed *int64
+	if canGenerate {
+		generate = flag.Int("generate", 0, "print random puzzle input of given `size` and exit")
+		seed = flag.Int64("seed", 1, "random seed for -generate")
+	}
+	var query *string
+	if canQuery {
+		query = flag.String("query", "", "answer a custom `question` about input instead of solving puzzle parts")
+	}
+	if canJobs {
+		flag.IntVar(&jobs, "jobs", 0, "number of parallel workers (default: GOMAXPROCS)")
+	}
+
+	flag.Parse()
+	if flag.NArg() > 1 {
+		return fmt.Errorf("unparsed command arguments left: %v", flag.NArg())
+	}
+	if flag.NArg() == 1 {
+		*input = flag.Args()[0]
+	}
+
+	if *list {
+		for _, solver := range Registered() {
+			fmt.Println(solver.Info())
+		}
+		return nil
+	}
+	solver, err := selectSolver(*year, *day)
+	if err != nil {
+		return err
+	}
+
+	if canGenerate && *generate != 0 {
+		generator, ok := generatorOf(solver)
+		if !ok {
+			return fmt.Errorf("%s: input generation is not supported", solver.Info())
+		}
+		text, err := generator.Generate(rand.New(rand.NewSource(*seed)), *generate)
+		if err != nil {
+			return fmt.Errorf("%s: %w", solver.Info(), err)
+		}
+		fmt.Print(text)
+		return nil
+	}
+	var querier Querier
+	if canQuery && *query != "" {
+		var ok bool
+		querier, ok = querierOf(solver)
+		if !ok {
+			return fmt.Errorf("%s: queries are not supported", solver.Info())
+		}
+	}
+
+	if *cpuprofile != "" {
+		log.Printf("Writing CPU profile to %s", *cpuprofile)
+		f, err := os.Create(*cpuprofile)
+		if err != nil {
+			return fmt.Errorf("could not create CPU profile: %w", err)
+		}
+		defer func() {
+			if err := f.Close(); err != nil {
+				panic(err)
+			}
+		}()
+		if err := pprof.StartCPUProfile(f); err != nil {
+			return fmt.Errorf("could not start CPU profile: %w", err)
+		}
+		defer pprof.StopCPUProfile()
+	}
+	if *memprofile != "" {
+		log.Printf("Writing memory profile to %s", *memprofile)
+		f, err := os.Create(*memprofile)
+		if err != nil {
+			return fmt.Errorf("could not create memory profile: %w", err)
+		}
+		defer func() {
+			if err := f.Close(); err != nil {
+				panic(err)
+			}
+		}()
+		defer func() {
+			runtime.GC() // get up-to-date statistics
+			if err := pprof.WriteHeapProfile(f); err != nil {
+				log.Printf("could not write memory profile: %v", err)
+			}
+		}()
+	}
+
+	var answers []string
+	if *check {
+		answers, err = ReadAnswers(filepath.Join(filepath.Dir(*input), "README"))
+		if err != nil {
+			return fmt.Errorf("could not read accepted answers: %w", err)
+		}
+	}
+
+	parts := []int{*part}
+	if *part == 0 {
+		parts = parts[:0]
+		for i := 1; i <= numberOfParts; i++ {
+			parts = append(parts, i)
+		}
+	}
+
+	start := time.Now()
+	parsed, err := solver.Parse(*input)
+	if err != nil {
+		return fmt.Errorf("%s: %w", solver.Info(), err)
+	}
+	log.Printf("Parse time: %v", time.Since(start))
+
+	if querier != nil {
+		start = time.Now()
+		result, err := querier.Query(parsed, *query)
+		if err != nil {
+			return fmt.Errorf("%s: %w", solver.Info(), err)
+		}
+		log.Printf("Query time: %v", time.Since(start))
+		report("Query", result)
+		return nil
+	}
+
+	var mismatch bool
+	for _, number := range parts {
+		start = time.Now()
+		result, err := solver.Solve(parsed, number)
+		if err != nil {
+			return err
+		}
+		log.Printf("Part %d solve time: %v", number, time.Since(start))
+		report(fmt.Sprintf("Part %d", number), result)
+		if *check && !verify(number, result, answers) {
+			mismatch = true
+		}
+		if *bench > 0 {
+			err = benchmark(solver, *input, number, *bench)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if mismatch {
+		return errMismatch
+	}
+	return nil
+}
+
+func report(label string, result string) {
+	var delimiter string
+	if strings.Contains(result, "\n") {
+		delimiter = "\n"
+	}
+	fmt.Printf("%s result: %s%s\n", label, delimiter, result)
+}
+
+// Compare result with accepted answer, rendered results are not checked
+func verify(part int, result string, answers []string) bool {
+	switch {
+	case part > len(answers):
+		fmt.Printf("Part %d check: no accepted answer found\n", part)
+	case strings.Contains(result, "\n"):
+		fmt.Printf("Part %d check: skipped for multiline result, accepted answer was %s\n", part, answers[part-1])
+	case result == answers[part-1]:
+		fmt.Printf("Part %d check: OK\n", part)
+	default:
+		fmt.Printf("Part %d check: FAILED, accepted answer was %s\n", part, answers[part-1])
+		return false
+	}
+	return true
+}
+
+func benchmark(solver Solver, input string, part int, runs int) error {
+	var parse, solve time.Duration
+	for i := 0; i < runs; i++ {
+		start := time.Now()
+		parsed, err := solver.Parse(input)
+		if err != nil {
+			return err
+		}
+		parse += time.Since(start)
+
+		start = time.Now()
+		_, err = solver.Solve(parsed, part)
+		if err != nil {
+			return err
+		}
+		solve += time.Since(start)
+	}
+	fmt.Printf(
+		"Part %d benchmark: %d runs, parse %v, solve %v per run\n",
+		part,
+		runs,
+		parse/time.Duration(runs),
+		solve/time.Duration(runs),
+	)
+	return nil
+}
@@ -0,0 +1,167 @@
+// Common interface for Advent of Code solutions
+//
+// Each day registers its Solver and hands control over to Main, which takes
+// care of command line flags, profiling, answer checking and benchmarking.
+// Solvers are keyed by year and day, so that packages for other years can
+// be linked into the same program and share the runner.
+package puzzle
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Puzzle metadata
+type Info struct {
+	Year  int
+	Day   int
+	Title string
+}
+
+func (info Info) String() string {
+	return fmt.Sprintf("%d/%02d: %s", info.Year, info.Day, info.Title)
+}
+
+// Parsed puzzle input, its type is up to the Solver
+type Input any
+
+// Solution for a single Advent of Code puzzle
+//
+// Input is parsed once and then passed to Solve for each part, Solve must not
+// mutate it.
+type Solver interface {
+	Info() Info
+	Parse(filename string) (Input, error)
+	Solve(input Input, part int) (string, error)
+}
+
+// Optional interface for solvers that can produce random puzzle input
+//
+// Generate returns an error if no valid input of the given size exists.
+type Generator interface {
+	Generate(rng *rand.Rand, size int) (string, error)
+}
+
+// Optional interface for solvers that answer free form questions about input
+type Querier interface {
+	Query(input Input, query string) (string, error)
+}
+
+// Parsed input that can be reused for several parts
+type Copier[T any] interface {
+	// Deep copy, so that mutations made while solving do not leak elsewhere
+	Copy() T
+}
+
+// Wrap solutions that parse input file once for all parts
+//
+// Each part receives its own copy of parsed input.
+func Parsed[T Copier[T]](info Info, parse func(filename string) (T, error), parts ...func(input T) string) Solver {
+	return &parsed[T]{info: info, parse: parse, parts: parts}
+}
+
+type parsed[T Copier[T]] struct {
+	info  Info
+	parse func(string) (T, error)
+	parts []func(T) string
+}
+
+func (p *parsed[T]) Info() Info {
+	return p.info
+}
+
+func (p *parsed[T]) Parse(filename string) (Input, error) {
+	return p.parse(filename)
+}
+
+func (p *parsed[T]) Solve(input Input, part int) (string, error) {
+	if part < 1 || part > len(p.parts) {
+		return "", fmt.Errorf("%s: invalid puzzle part: %d", p.info, part)
+	}
+	value, ok := input.(T)
+	if !ok {
+		return "", fmt.Errorf("%s: expected %T as input, got %T", p.info, value, input)
+	}
+	return p.parts[part-1](value.Copy()), nil
+}
+
+// Solver with optional features attached by With* functions
+//
+// Wrappers can be stacked in any order, each of them extends the same set
+// of features instead of hiding the ones added before.
+type extended struct {
+	Solver
+	generate func(*rand.Rand, int) (string, error)
+	query    func(Input, string) (string, error)
+	jobs     bool
+}
+
+func extend(solver Solver) *extended {
+	if e, ok := solver.(*extended); ok {
+		clone := *e
+		return &clone
+	}
+	return &extended{Solver: solver}
+}
+
+func (e *extended) Generate(rng *rand.Rand, size int) (string, error) {
+	return e.generate(rng, size)
+}
+
+func (e *extended) Query(input Input, query string) (string, error) {
+	return e.query(input, query)
+}
+
+// Input generation supported by solver, if any
+func generatorOf(solver Solver) (Generator, bool) {
+	if e, ok := solver.(*extended); ok {
+		return e, e.generate != nil
+	}
+	generator, ok := solver.(Generator)
+	return generator, ok
+}
+
+// Queries supported by solver, if any
+func querierOf(solver Solver) (Querier, bool) {
+	if e, ok := solver.(*extended); ok {
+		return e, e.query != nil
+	}
+	querier, ok := solver.(Querier)
+	return querier, ok
+}
+
+// Check if solver splits its work with ParallelMap
+func usesJobs(solver Solver) bool {
+	e, ok := solver.(*extended)
+	return ok && e.jobs
+}
+
+// Mark solver as the one that runs work units in parallel, this enables
+// -jobs flag
+func WithJobs(solver Solver) Solver {
+	e := extend(solver)
+	e.jobs = true
+	return e
+}
+
+// Add input generation to a solver
+func WithGenerator(solver Solver, generate func(rng *rand.Rand, size int) (string, error)) Solver {
+	e := extend(solver)
+	e.generate = generate
+	return e
+}
+
+// Add free form queries to a solver
+//
+// Query function receives its own copy of parsed input, same as puzzle parts.
+func WithQuery[T Copier[T]](solver Solver, query func(input T, query string) (string, error)) Solver {
+	e := extend(solver)
+	e.query = func(input Input, question string) (string, error) {
+		value, ok := input.(T)
+		if !ok {
+			return "", fmt.Errorf("%s: expected %T as input, got %T", e.Info(), value, input)
+		}
+		return query(value.Copy(), question)
+	}
+	return e
+}
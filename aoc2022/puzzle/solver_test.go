@@ -0,0 +1,197 @@
+package puzzle
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+type counter struct {
+	values []int
+}
+
+func (c *counter) Copy() *counter {
+	return &counter{values: append([]int(nil), c.values...)}
+}
+
+func TestParsed(t *testing.T) {
+	parsed := 0
+	parse := func(filename string) (*counter, error) {
+		parsed++
+		return &counter{values: []int{len(filename)}}, nil
+	}
+	mutate := func(c *counter) string {
+		c.values[0]++
+		c.values = append(c.values, 0)
+		return fmt.Sprint(c.values)
+	}
+	solver := Parsed(Info{Year: 2000, Day: 1, Title: "Test"}, parse, mutate, mutate)
+	input, err := solver.Parse("abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for part := 1; part <= 2; part++ {
+		result, err := solver.Solve(input, part)
+		if err != nil {
+			t.Fatalf("part %d: %v", part, err)
+		}
+		if result != "[4 0]" {
+			t.Errorf("part %d: mutations leaked between parts: %s", part, result)
+		}
+	}
+	if parsed != 1 {
+		t.Errorf("input was parsed %d times", parsed)
+	}
+	if _, err := solver.Solve("abc", 1); err == nil {
+		t.Errorf("unexpected input type: want error, got nil")
+	}
+}
+
+func TestQuery(t *testing.T) {
+	parse := func(filename string) (*counter, error) {
+		return &counter{values: []int{len(filename)}}, nil
+	}
+	solver := WithQuery(
+		Parsed(Info{Year: 2000, Day: 1, Title: "Test"}, parse),
+		func(c *counter, query string) (string, error) {
+			c.values[0]++
+			return fmt.Sprint(query, c.values), nil
+		},
+	)
+	querier, ok := querierOf(solver)
+	if !ok {
+		t.Fatalf("%T does not implement Querier", solver)
+	}
+	input, err := solver.Parse("abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		result, err := querier.Query(input, "q")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result != "q[4]" {
+			t.Errorf("query #%d: want %q, got %q", i+1, "q[4]", result)
+		}
+	}
+	if _, err := querier.Query("abc", "q"); err == nil {
+		t.Errorf("unexpected input type: want error, got nil")
+	}
+}
+
+func TestWrapperOrder(t *testing.T) {
+	parse := func(filename string) (*counter, error) {
+		return &counter{}, nil
+	}
+	query := func(c *counter, query string) (string, error) {
+		return query, nil
+	}
+	generate := func(rng *rand.Rand, size int) (string, error) {
+		return fmt.Sprint(size), nil
+	}
+	base := Parsed(Info{Year: 2000, Day: 1, Title: "Test"}, parse)
+	for _, solver := range []Solver{
+		WithGenerator(WithQuery(base, query), generate),
+		WithQuery(WithGenerator(base, generate), query),
+	} {
+		if _, ok := querierOf(solver); !ok {
+			t.Errorf("%T does not support queries", solver)
+		}
+		if _, ok := generatorOf(solver); !ok {
+			t.Errorf("%T does not support input generation", solver)
+		}
+	}
+	generating := WithGenerator(base, generate)
+	if _, ok := querierOf(generating); ok {
+		t.Errorf("solver without queries must not support them")
+	}
+	if _, ok := generatorOf(base); ok {
+		t.Errorf("plain solver must not support input generation")
+	}
+	if _, ok := querierOf(WithQuery(generating, query)); !ok {
+		t.Errorf("stacking wrappers lost queries")
+	}
+	if _, ok := querierOf(generating); ok {
+		t.Errorf("wrapping a solver modified the original one")
+	}
+	if usesJobs(generating) || !usesJobs(WithJobs(generating)) {
+		t.Errorf("-jobs must be enabled only by WithJobs")
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	defer func(saved map[key]Solver) { registry = saved }(registry)
+	registry = make(map[key]Solver)
+
+	parse := func(filename string) (*counter, error) {
+		return &counter{}, nil
+	}
+	if _, err := selectSolver(0, 0); err == nil {
+		t.Errorf("empty registry: want error, got nil")
+	}
+	for _, info := range []Info{
+		{Year: 2022, Day: 2, Title: "Second"},
+		{Year: 2021, Day: 25, Title: "Last"},
+		{Year: 2022, Day: 1, Title: "First"},
+	} {
+		Register(Parsed(info, parse))
+	}
+
+	var order []int
+	for _, solver := range Registered() {
+		order = append(order, solver.Info().Year*100+solver.Info().Day)
+	}
+	want := []int{202125, 202201, 202202}
+	if fmt.Sprint(order) != fmt.Sprint(want) {
+		t.Fatalf("want %v, got %v", want, order)
+	}
+
+	solver, ok := Lookup(2022, 2)
+	if !ok || solver.Info().Title != "Second" {
+		t.Errorf("lookup 2022/02: got %v, %v", solver, ok)
+	}
+	if _, ok := Lookup(2022, 3); ok {
+		t.Errorf("lookup 2022/03: solver was never registered")
+	}
+	if _, err := selectSolver(0, 0); err == nil {
+		t.Errorf("several solvers registered: want error without year and day, got nil")
+	}
+	if solver, err := selectSolver(2021, 25); err != nil || solver.Info().Title != "Last" {
+		t.Errorf("select 2021/25: got %v, %v", solver, err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("duplicate registration did not panic")
+		}
+	}()
+	Register(Parsed(Info{Year: 2022, Day: 1}, parse))
+}
+
+func TestReadAnswers(t *testing.T) {
+	tests := []struct {
+		readme  string
+		answers []string
+	}{
+		{"../day01/README", []string{"67016", "200116"}},
+		{"../day05/README", []string{"FRDSQRRCD", "HRFTQVWNN"}},
+		{"../day25/README", []string{"20===-20-020=0001-02"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.readme, func(t *testing.T) {
+			answers, err := ReadAnswers(tt.readme)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(answers) != len(tt.answers) {
+				t.Fatalf("want %q, got %q", tt.answers, answers)
+			}
+			for i := range answers {
+				if answers[i] != tt.answers[i] {
+					t.Errorf("part %d: want %q, got %q", i+1, tt.answers[i], answers[i])
+				}
+			}
+		})
+	}
+}